@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// checkpoint records how far an export got. Options must match on resume,
+// otherwise the output would mix different exports.
+type checkpoint struct {
+	Options exportOptions `json:"options"`
+	LastID  string        `json:"last_id"`
+	Rows    int           `json:"rows"`
+	Offset  int64         `json:"offset"`
+}
+
+type exportOptions struct {
+	Format         string `json:"format"`
+	MovieID        string `json:"movie_id,omitempty"`
+	UserID         string `json:"user_id,omitempty"`
+	From           string `json:"from,omitempty"`
+	To             string `json:"to,omitempty"`
+	IncludeDeleted bool   `json:"include_deleted"`
+	Gzip           bool   `json:"gzip"`
+}
+
+// loadCheckpoint returns nil when there is no checkpoint at path.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, err
+	}
+
+	return &cp, nil
+}
+
+// save replaces the checkpoint atomically.
+func (cp *checkpoint) save(path string) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
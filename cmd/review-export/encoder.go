@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"ap2final_review_service/internal/models"
+)
+
+type encoder interface {
+	WriteHeader() error
+	Encode(review models.Review) error
+	Flush() error
+}
+
+// exportRecord mirrors the review-import record, so exports can be imported
+// again. Ratings are on the configured rating scale.
+type exportRecord struct {
+	ID            string             `json:"id"`
+	UserID        string             `json:"user_id"`
+	MovieID       string             `json:"movie_id"`
+	Rating        float64            `json:"rating"`
+	Title         string             `json:"title,omitempty"`
+	Comment       string             `json:"comment"`
+	AspectRatings map[string]float64 `json:"aspect_ratings,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	IsDeleted     bool               `json:"is_deleted"`
+}
+
+func newExportRecord(review models.Review, scale models.RatingScale) exportRecord {
+	rec := exportRecord{
+		ID:        review.ID,
+		UserID:    review.UserID,
+		MovieID:   review.MovieID,
+		Rating:    scale.Denormalize(review.Rating),
+		Title:     review.Title,
+		Comment:   review.Comment,
+		CreatedAt: review.CreatedAt,
+		UpdatedAt: review.UpdatedAt,
+		IsDeleted: review.IsDeleted,
+	}
+
+	if len(review.AspectRatings) > 0 {
+		rec.AspectRatings = make(map[string]float64, len(review.AspectRatings))
+		for aspect, stars := range review.AspectRatings {
+			rec.AspectRatings[aspect] = scale.Denormalize(stars)
+		}
+	}
+
+	return rec
+}
+
+type jsonlEncoder struct {
+	enc   *json.Encoder
+	scale models.RatingScale
+}
+
+func newJSONLEncoder(w io.Writer, scale models.RatingScale) *jsonlEncoder {
+	return &jsonlEncoder{enc: json.NewEncoder(w), scale: scale}
+}
+
+func (e *jsonlEncoder) WriteHeader() error {
+	return nil
+}
+
+func (e *jsonlEncoder) Encode(review models.Review) error {
+	return e.enc.Encode(newExportRecord(review, e.scale))
+}
+
+func (e *jsonlEncoder) Flush() error {
+	return nil
+}
+
+// csvEncoder writes one row per review. The csv format keeps the aspect
+// ratings as a JSON object in a single column; the columnar format spreads
+// them over one aspect_<name> column per configured aspect so every column
+// holds a single typed value.
+type csvEncoder struct {
+	w        *csv.Writer
+	scale    models.RatingScale
+	aspects  models.Aspects
+	columnar bool
+}
+
+func newCSVEncoder(w io.Writer, scale models.RatingScale, aspects models.Aspects, columnar bool) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w), scale: scale, aspects: aspects, columnar: columnar}
+}
+
+func (e *csvEncoder) WriteHeader() error {
+	header := []string{"id", "user_id", "movie_id", "rating", "title", "comment"}
+
+	if e.columnar {
+		for _, aspect := range e.aspects {
+			header = append(header, "aspect_"+aspect)
+		}
+	} else {
+		header = append(header, "aspect_ratings")
+	}
+
+	header = append(header, "created_at", "updated_at", "is_deleted")
+
+	return e.w.Write(header)
+}
+
+func (e *csvEncoder) Encode(review models.Review) error {
+	rec := newExportRecord(review, e.scale)
+
+	row := []string{
+		rec.ID,
+		rec.UserID,
+		rec.MovieID,
+		formatFloat(rec.Rating),
+		rec.Title,
+		rec.Comment,
+	}
+
+	if e.columnar {
+		for _, aspect := range e.aspects {
+			value := ""
+			if rating, ok := rec.AspectRatings[aspect]; ok {
+				value = formatFloat(rating)
+			}
+			row = append(row, value)
+		}
+	} else {
+		aspects := ""
+		if len(rec.AspectRatings) > 0 {
+			b, err := json.Marshal(rec.AspectRatings)
+			if err != nil {
+				return err
+			}
+			aspects = string(b)
+		}
+		row = append(row, aspects)
+	}
+
+	row = append(
+		row,
+		rec.CreatedAt.UTC().Format(time.RFC3339),
+		rec.UpdatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatBool(rec.IsDeleted),
+	)
+
+	return e.w.Write(row)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	mongorepo "ap2final_review_service/internal/adapter/mongo"
+	"ap2final_review_service/internal/config"
+	"ap2final_review_service/internal/models"
+	"ap2final_review_service/pkg/logger"
+	mongocfg "ap2final_review_service/pkg/mongo"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// review-export dumps the reviews collection.
+//
+//	review-export [-config path] -output reviews.jsonl [-format jsonl|csv|columnar]
+//	              [-movie id] [-user id] [-from date] [-to date] [-include-deleted]
+//	              [-gzip] [-checkpoint file] [-checkpoint-every 10000]
+//
+// Reviews are written in id order and a checkpoint is saved every
+// -checkpoint-every rows. Rerunning the same command after an interruption
+// resumes from the last checkpoint; the checkpoint is removed once the
+// export completes.
+func main() {
+	var (
+		outputPath      string
+		checkpointPath  string
+		checkpointEvery int
+		opts            exportOptions
+	)
+
+	flag.StringVar(&outputPath, "output", "", "file to write the export to")
+	flag.StringVar(&opts.Format, "format", "jsonl", "output format: jsonl, csv or columnar")
+	flag.StringVar(&opts.MovieID, "movie", "", "only export reviews of this movie")
+	flag.StringVar(&opts.UserID, "user", "", "only export reviews of this user")
+	flag.StringVar(&opts.From, "from", "", "only export reviews created at or after this RFC 3339 time or date")
+	flag.StringVar(&opts.To, "to", "", "only export reviews created before this RFC 3339 time or date")
+	flag.BoolVar(&opts.IncludeDeleted, "include-deleted", false, "also export deleted reviews")
+	flag.BoolVar(&opts.Gzip, "gzip", false, "gzip the output")
+	flag.StringVar(&checkpointPath, "checkpoint", "", "checkpoint file, defaults to <output>.checkpoint")
+	flag.IntVar(&checkpointEvery, "checkpoint-every", 10000, "rows written between checkpoints")
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg := config.MustLoad()
+
+	log := logger.SetupLogger(cfg.Env)
+
+	if outputPath == "" || checkpointEvery < 1 {
+		log.Error("usage: review-export [-config path] -output file [-format jsonl|csv|columnar] [filters] [-gzip]")
+		os.Exit(2)
+	}
+
+	if checkpointPath == "" {
+		checkpointPath = outputPath + ".checkpoint"
+	}
+
+	filter, err := opts.filter()
+	if err != nil {
+		log.Error("invalid filter", logger.Err(err))
+		os.Exit(2)
+	}
+
+	scale := models.RatingScale{
+		Min:  cfg.Rating.Min,
+		Max:  cfg.Rating.Max,
+		Step: cfg.Rating.Step,
+	}
+	aspects := models.Aspects(cfg.Rating.Aspects)
+
+	cp, err := loadCheckpoint(checkpointPath)
+	if err != nil {
+		log.Error("failed to read checkpoint", logger.Err(err))
+		os.Exit(1)
+	}
+
+	if cp != nil {
+		if cp.Options != opts {
+			log.Error("checkpoint belongs to an export with different options, remove it to start over",
+				slog.String("checkpoint", checkpointPath))
+			os.Exit(1)
+		}
+
+		filter.AfterID = &cp.LastID
+
+		log.Info("resuming export", slog.String("after_id", cp.LastID), slog.Int("rows", cp.Rows))
+	} else {
+		cp = &checkpoint{Options: opts}
+	}
+
+	out, err := openOutput(outputPath, cp.Offset, opts.Gzip)
+	if err != nil {
+		log.Error("failed to open output", logger.Err(err))
+		os.Exit(1)
+	}
+
+	var enc encoder
+	switch opts.Format {
+	case "jsonl":
+		enc = newJSONLEncoder(out, scale)
+	case "csv":
+		enc = newCSVEncoder(out, scale, aspects, false)
+	case "columnar":
+		enc = newCSVEncoder(out, scale, aspects, true)
+	default:
+		log.Error("unsupported output format", slog.String("format", opts.Format))
+		os.Exit(2)
+	}
+
+	if cp.Offset == 0 {
+		if err := enc.WriteHeader(); err != nil {
+			log.Error("failed to write header", logger.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	db, err := mongocfg.NewDB(ctx, cfg.Mongo)
+	if err != nil {
+		log.Error("error connecting to mongo database", logger.Err(err))
+		os.Exit(1)
+	}
+
+	reviewRepo := mongorepo.NewReview(db.Connection, aspects)
+
+	save := func() error {
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+
+		offset, err := out.Sync()
+		if err != nil {
+			return err
+		}
+
+		cp.Offset = offset
+
+		return cp.save(checkpointPath)
+	}
+
+	log.Info("exporting reviews", slog.String("output", outputPath), slog.String("format", opts.Format))
+
+	err = reviewRepo.Stream(ctx, filter, func(review models.Review) error {
+		if err := enc.Encode(review); err != nil {
+			return err
+		}
+
+		cp.LastID = review.ID
+		cp.Rows++
+
+		if cp.Rows%checkpointEvery == 0 {
+			if err := save(); err != nil {
+				return err
+			}
+
+			log.Info("export checkpoint", slog.Int("rows", cp.Rows))
+		}
+
+		return nil
+	})
+	if err != nil {
+		if saveErr := save(); saveErr != nil {
+			log.Error("failed to save checkpoint", logger.Err(saveErr))
+		}
+
+		if errors.Is(err, context.Canceled) {
+			log.Warn("export interrupted, run the same command again to resume", slog.Int("rows", cp.Rows))
+		} else {
+			log.Error("export failed, run the same command again to resume", logger.Err(err))
+		}
+
+		os.Exit(1)
+	}
+
+	if err := enc.Flush(); err != nil {
+		log.Error("failed to write output", logger.Err(err))
+		os.Exit(1)
+	}
+
+	if err := out.Close(); err != nil {
+		log.Error("failed to close output", logger.Err(err))
+		os.Exit(1)
+	}
+
+	if err := os.Remove(checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn("failed to remove checkpoint", logger.Err(err))
+	}
+
+	log.Info("export finished", slog.Int("rows", cp.Rows), slog.String("output", outputPath))
+}
+
+func (o exportOptions) filter() (models.ReviewFilter, error) {
+	var filter models.ReviewFilter
+
+	if o.MovieID != "" {
+		filter.MovieID = models.StringPtr(o.MovieID)
+	}
+
+	if o.UserID != "" {
+		filter.UserID = models.StringPtr(o.UserID)
+	}
+
+	if !o.IncludeDeleted {
+		filter.IsDeleted = models.BoolPtr(false)
+	}
+
+	if o.From != "" {
+		from, err := parseTime(o.From)
+		if err != nil {
+			return models.ReviewFilter{}, err
+		}
+		filter.CreatedFrom = &from
+	}
+
+	if o.To != "" {
+		to, err := parseTime(o.To)
+		if err != nil {
+			return models.ReviewFilter{}, err
+		}
+		filter.CreatedTo = &to
+	}
+
+	return filter, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+// output is the export file. With compression every checkpoint closes the
+// current gzip member and opens a new one, so the file can be cut back to
+// any checkpoint and appended to; gzip readers decode the concatenated
+// members as one stream. Writes always go to the current member, so
+// encoders can keep writing to the output across checkpoints.
+type output struct {
+	file *os.File
+	gz   *gzip.Writer
+	buf  *bufio.Writer
+}
+
+// openOutput opens path for writing at offset, discarding anything written
+// after it.
+func openOutput(path string, offset int64, compress bool) (*output, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	o := &output{file: file}
+	o.reset(compress)
+
+	return o, nil
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	return o.buf.Write(p)
+}
+
+// Sync makes everything written so far durable and returns the file offset
+// to resume from.
+func (o *output) Sync() (int64, error) {
+	if err := o.buf.Flush(); err != nil {
+		return 0, err
+	}
+
+	compress := o.gz != nil
+	if compress {
+		if err := o.gz.Close(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := o.file.Sync(); err != nil {
+		return 0, err
+	}
+
+	offset, err := o.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	o.reset(compress)
+
+	return offset, nil
+}
+
+func (o *output) Close() error {
+	if _, err := o.Sync(); err != nil {
+		o.file.Close()
+		return err
+	}
+
+	return o.file.Close()
+}
+
+func (o *output) reset(compress bool) {
+	var w io.Writer = o.file
+
+	o.gz = nil
+	if compress {
+		o.gz = gzip.NewWriter(o.file)
+		w = o.gz
+	}
+
+	o.buf = bufio.NewWriter(w)
+}
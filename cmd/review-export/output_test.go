@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ap2final_review_service/internal/models"
+)
+
+const (
+	testRecords         = 25
+	testCheckpointEvery = 10
+)
+
+func TestOutputRoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gzip=%t", compress), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "reviews.jsonl")
+
+			out, err := openOutput(path, 0, compress)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			enc := newJSONLEncoder(out, models.DefaultRatingScale)
+			for i := 0; i < testRecords; i++ {
+				encodeTestReview(t, enc, i)
+
+				if (i+1)%testCheckpointEvery == 0 {
+					if _, err := out.Sync(); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			if err := out.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			assertTestReviews(t, path, compress)
+		})
+	}
+}
+
+func TestOutputResume(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gzip=%t", compress), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "reviews.jsonl")
+
+			out, err := openOutput(path, 0, compress)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			enc := newJSONLEncoder(out, models.DefaultRatingScale)
+			for i := 0; i < testCheckpointEvery; i++ {
+				encodeTestReview(t, enc, i)
+			}
+
+			offset, err := out.Sync()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Rows after the checkpoint are lost when the export is
+			// interrupted and written again on resume.
+			for i := testCheckpointEvery; i < testCheckpointEvery+5; i++ {
+				encodeTestReview(t, enc, i)
+			}
+			if err := out.buf.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			out.file.Close()
+
+			out, err = openOutput(path, offset, compress)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			enc = newJSONLEncoder(out, models.DefaultRatingScale)
+			for i := testCheckpointEvery; i < testRecords; i++ {
+				encodeTestReview(t, enc, i)
+			}
+
+			if err := out.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			assertTestReviews(t, path, compress)
+		})
+	}
+}
+
+func encodeTestReview(t *testing.T, enc encoder, i int) {
+	t.Helper()
+
+	review := models.Review{ID: fmt.Sprintf("review-%02d", i), Rating: 4}
+	if err := enc.Encode(review); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertTestReviews(t *testing.T, path string, compress bool) {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if compress {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var rec exportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("line %d: %v", len(ids)+1, err)
+		}
+		ids = append(ids, rec.ID)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != testRecords {
+		t.Fatalf("got %d records, want %d", len(ids), testRecords)
+	}
+	for i, id := range ids {
+		if want := fmt.Sprintf("review-%02d", i); id != want {
+			t.Fatalf("record %d: got id %q, want %q", i, id, want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	mongorepo "ap2final_review_service/internal/adapter/mongo"
+	"ap2final_review_service/internal/models"
+)
+
+type importer struct {
+	reviewRepo mongorepo.ReviewRepository
+	statsRepo  mongorepo.RatingStatsRepository
+	scale      models.RatingScale
+	aspects    models.Aspects
+	batchSize  int
+	dryRun     bool
+	rejects    *json.Encoder
+	log        *slog.Logger
+
+	seen    map[models.UserMovie]bool
+	pending []pendingReview
+	summary importSummary
+}
+
+type pendingReview struct {
+	line   int
+	raw    string
+	review models.Review
+}
+
+type importSummary struct {
+	Read     int
+	Inserted int
+	Rejected int
+}
+
+type reject struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+	Record string `json:"record"`
+}
+
+func (imp *importer) run(ctx context.Context, reader recordReader) (importSummary, error) {
+	imp.seen = make(map[models.UserMovie]bool)
+
+	for {
+		rec, line, raw, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var invalid invalidRecordError
+		if errors.As(err, &invalid) {
+			imp.summary.Read++
+			if err := imp.reject(line, raw, err); err != nil {
+				return imp.summary, err
+			}
+			continue
+		}
+		if err != nil {
+			return imp.summary, err
+		}
+
+		imp.summary.Read++
+
+		review, err := rec.toReview(imp.scale, imp.aspects, time.Now())
+		if err != nil {
+			if err := imp.reject(line, raw, err); err != nil {
+				return imp.summary, err
+			}
+			continue
+		}
+
+		key := models.UserMovie{UserID: review.UserID, MovieID: review.MovieID}
+		if imp.seen[key] {
+			if err := imp.reject(line, raw, errors.New("duplicate of an earlier record for this user and movie")); err != nil {
+				return imp.summary, err
+			}
+			continue
+		}
+		imp.seen[key] = true
+
+		imp.pending = append(imp.pending, pendingReview{line: line, raw: raw, review: review})
+
+		if len(imp.pending) >= imp.batchSize {
+			if err := imp.flush(ctx); err != nil {
+				return imp.summary, err
+			}
+		}
+	}
+
+	if err := imp.flush(ctx); err != nil {
+		return imp.summary, err
+	}
+
+	return imp.summary, nil
+}
+
+// flush drops the pending reviews that already exist in the database and
+// inserts the rest, updating the movie rating stats of the inserted ones.
+func (imp *importer) flush(ctx context.Context) error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+
+	batch := imp.pending
+	imp.pending = nil
+
+	pairs := make([]models.UserMovie, 0, len(batch))
+	for _, p := range batch {
+		pairs = append(pairs, models.UserMovie{UserID: p.review.UserID, MovieID: p.review.MovieID})
+	}
+
+	existing, err := imp.reviewRepo.ExistingUserMovies(ctx, pairs)
+	if err != nil {
+		return err
+	}
+
+	toInsert := make([]pendingReview, 0, len(batch))
+	for i, p := range batch {
+		if existing[pairs[i]] {
+			if err := imp.reject(p.line, p.raw, models.ErrReviewAlreadyExists); err != nil {
+				return err
+			}
+			continue
+		}
+		toInsert = append(toInsert, p)
+	}
+
+	if imp.dryRun {
+		imp.summary.Inserted += len(toInsert)
+		return nil
+	}
+
+	reviews := make([]*models.Review, 0, len(toInsert))
+	for i := range toInsert {
+		reviews = append(reviews, &toInsert[i].review)
+	}
+
+	errs, err := imp.reviewRepo.InsertMany(ctx, reviews)
+	if err != nil {
+		return err
+	}
+
+	stats := make(map[string]models.MovieRatingStats)
+
+	for i, p := range toInsert {
+		if errs[i] != nil {
+			if err := imp.reject(p.line, p.raw, errs[i]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		imp.summary.Inserted++
+
+		movieStats := stats[p.review.MovieID]
+		movieStats.Add(p.review)
+		stats[p.review.MovieID] = movieStats
+	}
+
+	for movieID, delta := range stats {
+		if err := imp.statsRepo.Apply(ctx, delta); err != nil {
+			imp.log.Error(
+				"failed to update movie rating stats, run review-stats rebuild",
+				slog.String("movie_id", movieID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	imp.log.Info(
+		"imported batch",
+		slog.Int("read", imp.summary.Read),
+		slog.Int("inserted", imp.summary.Inserted),
+		slog.Int("rejected", imp.summary.Rejected),
+	)
+
+	return nil
+}
+
+func (imp *importer) reject(line int, raw string, reason error) error {
+	imp.summary.Rejected++
+
+	return imp.rejects.Encode(reject{
+		Line:   line,
+		Reason: reason.Error(),
+		Record: raw,
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	mongorepo "ap2final_review_service/internal/adapter/mongo"
+	"ap2final_review_service/internal/config"
+	"ap2final_review_service/internal/models"
+	"ap2final_review_service/pkg/logger"
+	mongocfg "ap2final_review_service/pkg/mongo"
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// review-import loads reviews exported from a legacy system.
+//
+//	review-import [-config path] -input reviews.csv [-format csv|jsonl]
+//	              [-batch 500] [-rejects rejects.jsonl] [-dry-run]
+//
+// Records are validated like reviews created through the API, ratings are
+// read on the configured rating scale and only one review per user and
+// movie is kept. Rejected records are written to the rejects file as JSON
+// lines with their line number and reason.
+func main() {
+	var (
+		input       string
+		format      string
+		rejectsPath string
+		batchSize   int
+		dryRun      bool
+	)
+
+	flag.StringVar(&input, "input", "", "csv or json lines file to import")
+	flag.StringVar(&format, "format", "", "input format: csv or jsonl, detected from the extension by default")
+	flag.StringVar(&rejectsPath, "rejects", "", "file for rejected records, defaults to <input>.rejects.jsonl")
+	flag.IntVar(&batchSize, "batch", 500, "number of reviews inserted per batch")
+	flag.BoolVar(&dryRun, "dry-run", false, "validate and report without inserting")
+
+	ctx := context.Background()
+
+	cfg := config.MustLoad()
+
+	log := logger.SetupLogger(cfg.Env)
+
+	if input == "" || batchSize < 1 {
+		log.Error("usage: review-import [-config path] -input file [-format csv|jsonl] [-batch n] [-rejects file] [-dry-run]")
+		os.Exit(2)
+	}
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(input)), ".")
+	}
+
+	if rejectsPath == "" {
+		rejectsPath = input + ".rejects.jsonl"
+	}
+
+	scale := models.RatingScale{
+		Min:  cfg.Rating.Min,
+		Max:  cfg.Rating.Max,
+		Step: cfg.Rating.Step,
+	}
+	if err := scale.Validate(); err != nil {
+		log.Error("invalid rating scale", logger.Err(err))
+		os.Exit(1)
+	}
+
+	aspects := models.Aspects(cfg.Rating.Aspects)
+	if err := aspects.Validate(); err != nil {
+		log.Error("invalid rating aspects", logger.Err(err))
+		os.Exit(1)
+	}
+
+	file, err := os.Open(input)
+	if err != nil {
+		log.Error("failed to open input", logger.Err(err))
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	var reader recordReader
+	switch format {
+	case "csv":
+		reader, err = newCSVReader(file)
+		if err != nil {
+			log.Error("failed to read input", logger.Err(err))
+			os.Exit(1)
+		}
+	case "jsonl", "ndjson":
+		reader = newJSONLReader(file)
+	default:
+		log.Error("unsupported input format", slog.String("format", format))
+		os.Exit(2)
+	}
+
+	rejectsFile, err := os.Create(rejectsPath)
+	if err != nil {
+		log.Error("failed to create rejects file", logger.Err(err))
+		os.Exit(1)
+	}
+	defer rejectsFile.Close()
+
+	db, err := mongocfg.NewDB(ctx, cfg.Mongo)
+	if err != nil {
+		log.Error("error connecting to mongo database", logger.Err(err))
+		os.Exit(1)
+	}
+
+	imp := &importer{
+		reviewRepo: mongorepo.NewReview(db.Connection, aspects),
+		statsRepo:  mongorepo.NewRatingStats(db.Connection),
+		scale:      scale,
+		aspects:    aspects,
+		batchSize:  batchSize,
+		dryRun:     dryRun,
+		rejects:    json.NewEncoder(rejectsFile),
+		log:        log,
+	}
+
+	log.Info("importing reviews", slog.String("input", input), slog.String("format", format), slog.Bool("dry_run", dryRun))
+
+	summary, err := imp.run(ctx, reader)
+
+	log.Info(
+		"import finished",
+		slog.Int("read", summary.Read),
+		slog.Int("inserted", summary.Inserted),
+		slog.Int("rejected", summary.Rejected),
+		slog.String("rejects", rejectsPath),
+		slog.Bool("dry_run", dryRun),
+	)
+
+	if err != nil {
+		log.Error("import failed", logger.Err(err))
+		os.Exit(1)
+	}
+}
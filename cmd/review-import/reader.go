@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"ap2final_review_service/internal/models"
+)
+
+// Aspect ratings are read from one aspect_<name> column per aspect or from
+// a JSON object in the aspect_ratings column.
+const (
+	aspectColumnPrefix  = "aspect_"
+	aspectRatingsColumn = "aspect_ratings"
+)
+
+// record is one review of the legacy export. Ratings are on the configured
+// rating scale.
+type record struct {
+	UserID        string             `json:"user_id"`
+	MovieID       string             `json:"movie_id"`
+	Rating        float64            `json:"rating"`
+	Title         string             `json:"title"`
+	Comment       string             `json:"comment"`
+	AspectRatings map[string]float64 `json:"aspect_ratings"`
+	CreatedAt     *time.Time         `json:"created_at"`
+}
+
+type recordReader interface {
+	// Next returns the next record with its line number and raw text. A
+	// malformed line yields an invalidRecordError and reading can go on;
+	// io.EOF marks the end of the input.
+	Next() (record, int, string, error)
+}
+
+type invalidRecordError struct {
+	err error
+}
+
+func (e invalidRecordError) Error() string {
+	return e.err.Error()
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	return &jsonlReader{scanner: scanner}
+}
+
+func (r *jsonlReader) Next() (record, int, string, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		raw := r.scanner.Text()
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			return record{}, r.line, raw, invalidRecordError{fmt.Errorf("invalid json: %w", err)}
+		}
+
+		return rec, r.line, raw, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return record{}, r.line, "", err
+	}
+
+	return record{}, r.line, "", io.EOF
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, required := range []string{"user_id", "movie_id", "rating", "comment"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv header is missing column %q", required)
+		}
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Next() (record, int, string, error) {
+	fields, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return record{}, 0, "", io.EOF
+	}
+
+	raw := strings.Join(fields, ",")
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return record{}, parseErr.Line, raw, invalidRecordError{fmt.Errorf("invalid csv: %w", err)}
+	}
+	if err != nil {
+		return record{}, 0, raw, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+
+	rec, err := r.parse(fields)
+	if err != nil {
+		return record{}, line, raw, invalidRecordError{err}
+	}
+
+	return rec, line, raw, nil
+}
+
+func (r *csvReader) parse(fields []string) (record, error) {
+	get := func(column string) string {
+		i, ok := r.columns[column]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+
+	rec := record{
+		UserID:  get("user_id"),
+		MovieID: get("movie_id"),
+		Title:   get("title"),
+		Comment: get("comment"),
+	}
+
+	rating, err := strconv.ParseFloat(get("rating"), 64)
+	if err != nil {
+		return record{}, fmt.Errorf("invalid rating %q", get("rating"))
+	}
+	rec.Rating = rating
+
+	if aspects := get(aspectRatingsColumn); aspects != "" {
+		if err := json.Unmarshal([]byte(aspects), &rec.AspectRatings); err != nil {
+			return record{}, fmt.Errorf("invalid aspect ratings %q", aspects)
+		}
+	}
+
+	for column := range r.columns {
+		aspect, ok := strings.CutPrefix(column, aspectColumnPrefix)
+		if !ok || column == aspectRatingsColumn || get(column) == "" {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(get(column), 64)
+		if err != nil {
+			return record{}, fmt.Errorf("invalid %s rating %q", aspect, get(column))
+		}
+
+		if rec.AspectRatings == nil {
+			rec.AspectRatings = map[string]float64{}
+		}
+		rec.AspectRatings[aspect] = value
+	}
+
+	if createdAt := get("created_at"); createdAt != "" {
+		t, err := time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			return record{}, fmt.Errorf("invalid created_at %q", createdAt)
+		}
+		rec.CreatedAt = &t
+	}
+
+	return rec, nil
+}
+
+// toReview converts the record into a validated review in stars.
+func (rec record) toReview(scale models.RatingScale, aspects models.Aspects, now time.Time) (models.Review, error) {
+	stars, err := scale.Normalize(rec.Rating)
+	if err != nil {
+		return models.Review{}, err
+	}
+
+	var aspectRatings map[string]float64
+	for aspect, value := range rec.AspectRatings {
+		aspectStars, err := scale.Normalize(value)
+		if err != nil {
+			return models.Review{}, models.ErrInvalidAspectRating
+		}
+
+		if aspectRatings == nil {
+			aspectRatings = make(map[string]float64, len(rec.AspectRatings))
+		}
+		aspectRatings[aspect] = aspectStars
+	}
+
+	review := models.Review{
+		UserID:        rec.UserID,
+		MovieID:       rec.MovieID,
+		Rating:        stars,
+		Title:         rec.Title,
+		AspectRatings: aspectRatings,
+		Comment:       rec.Comment,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if rec.CreatedAt != nil {
+		review.CreatedAt = *rec.CreatedAt
+		review.UpdatedAt = *rec.CreatedAt
+	}
+
+	if err := review.Validate(aspects); err != nil {
+		return models.Review{}, err
+	}
+
+	return review, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	mongorepo "ap2final_review_service/internal/adapter/mongo"
+	"ap2final_review_service/internal/config"
+	"ap2final_review_service/pkg/logger"
+	mongocfg "ap2final_review_service/pkg/mongo"
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+)
+
+// review-stats maintains the movie_rating_stats collection.
+//
+//	review-stats [-config path] rebuild   recompute the stats of every movie
+//	review-stats [-config path] check     report movies whose stats drifted
+//
+// Stats only count the writes made since they were introduced, so rebuild
+// has to run once against reviews written before, and again to clear
+// stats invalidated by a failed write.
+func main() {
+	ctx := context.Background()
+
+	cfg := config.MustLoad()
+
+	log := logger.SetupLogger(cfg.Env)
+
+	command := flag.Arg(0)
+	if command != "rebuild" && command != "check" {
+		log.Error("usage: review-stats [-config path] rebuild|check")
+		os.Exit(2)
+	}
+
+	db, err := mongocfg.NewDB(ctx, cfg.Mongo)
+	if err != nil {
+		log.Error("error connecting to mongo database", logger.Err(err))
+		os.Exit(1)
+	}
+
+	statsRepo := mongorepo.NewRatingStats(db.Connection)
+
+	switch command {
+	case "rebuild":
+		log.Info("rebuilding movie rating stats")
+
+		movies, err := statsRepo.Rebuild(ctx)
+		if err != nil {
+			log.Error("failed to rebuild movie rating stats", logger.Err(err))
+			os.Exit(1)
+		}
+
+		log.Info("movie rating stats rebuilt", slog.Int("movies", movies))
+	case "check":
+		log.Info("checking movie rating stats")
+
+		mismatches, err := statsRepo.Check(ctx)
+		if err != nil {
+			log.Error("failed to check movie rating stats", logger.Err(err))
+			os.Exit(1)
+		}
+
+		for _, mismatch := range mismatches {
+			log.Warn(
+				"movie rating stats mismatch",
+				slog.String("movie_id", mismatch.MovieID),
+				slog.Int("stored_count", mismatch.Stored.Count),
+				slog.Int("expected_count", mismatch.Expected.Count),
+				slog.Float64("stored_sum", mismatch.Stored.Sum),
+				slog.Float64("expected_sum", mismatch.Expected.Sum),
+			)
+		}
+
+		if len(mismatches) > 0 {
+			log.Error("movie rating stats are inconsistent", slog.Int("movies", len(mismatches)))
+			os.Exit(1)
+		}
+
+		log.Info("movie rating stats are consistent")
+	}
+}
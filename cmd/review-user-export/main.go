@@ -0,0 +1,85 @@
+package main
+
+import (
+	"ap2final_review_service/internal/adapter/archive"
+	mongorepo "ap2final_review_service/internal/adapter/mongo"
+	"ap2final_review_service/internal/config"
+	"ap2final_review_service/internal/models"
+	"ap2final_review_service/internal/usecase"
+	"ap2final_review_service/pkg/logger"
+	mongocfg "ap2final_review_service/pkg/mongo"
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+)
+
+// review-user-export writes everything the review service holds about a
+// user to a JSON archive, for subject access requests.
+//
+//	review-user-export [-config path] -user id -output archive.json
+//
+// The archive is the same document the ExportUserData RPC returns. It is
+// written to a file since the logger writes to stdout. It holds reviews
+// only; revisions, votes and reports do not exist in this service.
+func main() {
+	var (
+		userID     string
+		outputPath string
+	)
+
+	flag.StringVar(&userID, "user", "", "id of the user to export")
+	flag.StringVar(&outputPath, "output", "", "file to write the archive to")
+
+	ctx := context.Background()
+
+	cfg := config.MustLoad()
+
+	log := logger.SetupLogger(cfg.Env)
+
+	if userID == "" || outputPath == "" {
+		log.Error("usage: review-user-export [-config path] -user id -output file")
+		os.Exit(2)
+	}
+
+	scale := models.RatingScale{
+		Min:  cfg.Rating.Min,
+		Max:  cfg.Rating.Max,
+		Step: cfg.Rating.Step,
+	}
+
+	db, err := mongocfg.NewDB(ctx, cfg.Mongo)
+	if err != nil {
+		log.Error("error connecting to mongo database", logger.Err(err))
+		os.Exit(1)
+	}
+
+	privacyUseCase := usecase.NewPrivacyUseCase(
+		mongorepo.NewReview(db.Connection, cfg.Rating.Aspects),
+		mongorepo.NewRatingStats(db.Connection),
+		mongorepo.NewErasureReceipt(db.Connection),
+		mongorepo.NewIdempotency(db.Connection),
+		nil,
+		nil,
+		log,
+	)
+
+	export, err := privacyUseCase.ExportUserData(ctx, userID)
+	if err != nil {
+		log.Error("failed to export user data", logger.Err(err))
+		os.Exit(1)
+	}
+
+	data, err := archive.EncodeUserData(export, scale)
+	if err != nil {
+		log.Error("failed to encode user data", logger.Err(err))
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(outputPath, data, 0o600); err != nil {
+		log.Error("failed to write archive", logger.Err(err))
+		os.Exit(1)
+	}
+
+	log.Info("user data exported", slog.Int("reviews", len(export.Reviews)), slog.String("output", outputPath))
+}
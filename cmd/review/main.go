@@ -3,8 +3,8 @@ package main
 import (
 	"ap2final_review_service/internal/app"
 	"ap2final_review_service/internal/config"
+	"ap2final_review_service/pkg/logger"
 	"context"
-	"github.com/sorawaslocked/ap2final_base/pkg/logger"
 )
 
 func main() {
@@ -0,0 +1,78 @@
+package archive
+
+import (
+	"ap2final_review_service/internal/models"
+	"encoding/json"
+	"time"
+)
+
+const formatVersion = 1
+
+type userData struct {
+	FormatVersion int       `json:"format_version"`
+	Service       string    `json:"service"`
+	UserID        string    `json:"user_id"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	RatingScale   scale     `json:"rating_scale"`
+	Reviews       []review  `json:"reviews"`
+}
+
+type scale struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Step float64 `json:"step"`
+}
+
+type review struct {
+	ID            string             `json:"id"`
+	MovieID       string             `json:"movie_id"`
+	Rating        float64            `json:"rating"`
+	Title         string             `json:"title,omitempty"`
+	AspectRatings map[string]float64 `json:"aspect_ratings,omitempty"`
+	Comment       string             `json:"comment"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	IsDeleted     bool               `json:"is_deleted"`
+}
+
+// EncodeUserData renders a subject access export as an indented JSON
+// document with ratings on the given scale. The document lists reviews
+// only, since revisions, votes and reports are not stored by this service.
+func EncodeUserData(export models.UserDataExport, ratingScale models.RatingScale) ([]byte, error) {
+	data := userData{
+		FormatVersion: formatVersion,
+		Service:       "review",
+		UserID:        export.UserID,
+		GeneratedAt:   export.GeneratedAt.UTC(),
+		RatingScale: scale{
+			Min:  ratingScale.Min,
+			Max:  ratingScale.Max,
+			Step: ratingScale.Step,
+		},
+		Reviews: make([]review, 0, len(export.Reviews)),
+	}
+
+	for _, r := range export.Reviews {
+		item := review{
+			ID:        r.ID,
+			MovieID:   r.MovieID,
+			Rating:    ratingScale.Denormalize(r.Rating),
+			Title:     r.Title,
+			Comment:   r.Comment,
+			CreatedAt: r.CreatedAt.UTC(),
+			UpdatedAt: r.UpdatedAt.UTC(),
+			IsDeleted: r.IsDeleted,
+		}
+
+		if len(r.AspectRatings) > 0 {
+			item.AspectRatings = make(map[string]float64, len(r.AspectRatings))
+			for aspect, stars := range r.AspectRatings {
+				item.AspectRatings[aspect] = ratingScale.Denormalize(stars)
+			}
+		}
+
+		data.Reviews = append(data.Reviews, item)
+	}
+
+	return json.MarshalIndent(data, "", "  ")
+}
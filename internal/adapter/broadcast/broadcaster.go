@@ -0,0 +1,110 @@
+package broadcast
+
+import (
+	"ap2final_review_service/internal/models"
+	"log/slog"
+	"sync"
+)
+
+// Broadcaster fans review events out to the in-process subscribers of a
+// movie. Publishing never blocks: a subscriber whose buffer is full is
+// dropped and its channel closed, so it can resubscribe.
+type Broadcaster struct {
+	mu         sync.Mutex
+	subs       map[string]map[*subscription]struct{}
+	bufferSize int
+	closed     bool
+	log        *slog.Logger
+}
+
+type subscription struct {
+	movieID string
+	ch      chan models.ReviewEvent
+}
+
+func New(bufferSize int, log *slog.Logger) *Broadcaster {
+	return &Broadcaster{
+		subs:       make(map[string]map[*subscription]struct{}),
+		bufferSize: bufferSize,
+		log:        log,
+	}
+}
+
+func (b *Broadcaster) Publish(event models.ReviewEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs[event.Review.MovieID] {
+		select {
+		case sub.ch <- event:
+		default:
+			b.log.Warn(
+				"dropping slow review event subscriber",
+				slog.String("movie_id", sub.movieID),
+			)
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe returns the events of a movie and a function that cancels the
+// subscription. The channel is closed once the subscription ends.
+func (b *Broadcaster) Subscribe(movieID string) (<-chan models.ReviewEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &subscription{
+		movieID: movieID,
+		ch:      make(chan models.ReviewEvent, b.bufferSize),
+	}
+
+	if b.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+
+	if b.subs[movieID] == nil {
+		b.subs[movieID] = make(map[*subscription]struct{})
+	}
+	b.subs[movieID][sub] = struct{}{}
+
+	return sub.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.remove(sub)
+	}
+}
+
+// Close ends every subscription. Streams waiting on events return, which
+// lets the grpc server stop gracefully.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.remove(sub)
+		}
+	}
+}
+
+func (b *Broadcaster) remove(sub *subscription) {
+	subs, ok := b.subs[sub.movieID]
+	if !ok {
+		return
+	}
+
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	close(sub.ch)
+
+	if len(subs) == 0 {
+		delete(b.subs, sub.movieID)
+	}
+}
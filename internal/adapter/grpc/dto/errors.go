@@ -17,13 +17,97 @@ func FromError(err error) error {
 	}
 
 	if errors.Is(err, models.ErrInvalidRating) {
-		return status.Error(codes.InvalidArgument, "rating must be between 1 and 5")
+		return status.Error(codes.InvalidArgument, "rating is outside the rating scale")
 	}
 
 	if errors.Is(err, models.ErrEmptyComment) {
 		return status.Error(codes.InvalidArgument, "comment cannot be empty")
 	}
 
+	if errors.Is(err, models.ErrTitleTooLong) {
+		return status.Error(codes.InvalidArgument, "title must be at most 120 characters")
+	}
+
+	if errors.Is(err, models.ErrUnknownAspect) {
+		return status.Error(codes.InvalidArgument, "unknown rating aspect")
+	}
+
+	if errors.Is(err, models.ErrInvalidAspectRating) {
+		return status.Error(codes.InvalidArgument, "aspect rating is outside the rating scale")
+	}
+
+	if errors.Is(err, models.ErrInvalidLimit) {
+		return status.Error(codes.InvalidArgument, "limit must be between 1 and 100")
+	}
+
+	if errors.Is(err, models.ErrBatchTooLarge) {
+		return status.Error(codes.InvalidArgument, "batch must contain at most 100 ids")
+	}
+
+	if errors.Is(err, models.ErrInvalidLeaderboardSort) {
+		return status.Error(codes.InvalidArgument, "sort must be one of average, weighted or volume")
+	}
+
+	if errors.Is(err, models.ErrInvalidWindow) {
+		return status.Error(codes.InvalidArgument, "window must be between 0 and 365 days")
+	}
+
+	if errors.Is(err, models.ErrInvalidOffset) {
+		return status.Error(codes.InvalidArgument, "offset cannot be negative")
+	}
+
+	if errors.Is(err, models.ErrInvalidMinReviews) {
+		return status.Error(codes.InvalidArgument, "minimum reviews cannot be negative")
+	}
+
+	if errors.Is(err, models.ErrInvalidBucketSize) {
+		return status.Error(codes.InvalidArgument, "bucket size must be one of day, week or month")
+	}
+
+	if errors.Is(err, models.ErrInvalidTimeRange) {
+		return status.Error(codes.InvalidArgument, "time range start must be before its end")
+	}
+
+	if errors.Is(err, models.ErrTooManyBuckets) {
+		return status.Error(codes.InvalidArgument, "time range spans too many buckets")
+	}
+
+	if errors.Is(err, models.ErrInvalidErasureMode) {
+		return status.Error(codes.InvalidArgument, "erasure mode must be delete or anonymize")
+	}
+
+	if errors.Is(err, models.ErrErasureDisabled) {
+		return status.Error(codes.FailedPrecondition, "erasure is not configured")
+	}
+
+	if errors.Is(err, models.ErrInvalidUpdateMask) {
+		return status.Error(codes.InvalidArgument, "update mask is empty or names a field that cannot be updated")
+	}
+
+	if errors.Is(err, models.ErrFieldNotClearable) {
+		return status.Error(codes.InvalidArgument, "rating and comment cannot be cleared")
+	}
+
+	if errors.Is(err, models.ErrVersionConflict) {
+		return status.Error(codes.Aborted, "review was modified by another request")
+	}
+
+	if errors.Is(err, models.ErrInvalidVersion) {
+		return status.Error(codes.InvalidArgument, "version must be a non-negative integer")
+	}
+
+	if errors.Is(err, models.ErrInvalidIdempotencyKey) {
+		return status.Error(codes.InvalidArgument, "idempotency key must be 1 to 128 printable characters")
+	}
+
+	if errors.Is(err, models.ErrIdempotencyKeyReused) {
+		return status.Error(codes.InvalidArgument, "idempotency key was already used with a different request")
+	}
+
+	if errors.Is(err, models.ErrIdempotencyInProgress) {
+		return status.Error(codes.Aborted, "a request with this idempotency key is still in progress")
+	}
+
 	if errors.Is(err, models.ErrInvalidInput) {
 		return status.Error(codes.InvalidArgument, "invalid input data")
 	}
@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"ap2final_review_service/internal/models"
+	svc "github.com/sorawaslocked/ap2final_protos_gen/service/review"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func FromReviewEventToPb(event models.ReviewEvent, scale models.RatingScale) *svc.SubscribeMovieReviewsResponse {
+	return &svc.SubscribeMovieReviewsResponse{
+		Type:       string(event.Type),
+		Review:     FromReviewToPb(event.Review, scale),
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+}
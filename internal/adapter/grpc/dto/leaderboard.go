@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"ap2final_review_service/internal/models"
+	svc "github.com/sorawaslocked/ap2final_protos_gen/service/review"
+)
+
+func ToLeaderboardQueryFromRequest(req *svc.GetMovieLeaderboardRequest) models.LeaderboardQuery {
+	query := models.LeaderboardQuery{
+		SortBy:     models.LeaderboardSort(req.SortBy),
+		MinReviews: int(req.MinReviews),
+		Limit:      int(req.Limit),
+		Offset:     int(req.Offset),
+	}
+
+	if req.Window != nil {
+		query.Window = req.Window.AsDuration()
+	}
+
+	return query
+}
+
+func FromLeaderboardToPb(leaderboard models.Leaderboard, scale models.RatingScale) *svc.GetMovieLeaderboardResponse {
+	entriesPb := make([]*svc.LeaderboardEntry, 0, len(leaderboard.Entries))
+	for _, entry := range leaderboard.Entries {
+		entriesPb = append(entriesPb, &svc.LeaderboardEntry{
+			Rank:          int32(entry.Rank),
+			MovieID:       entry.MovieID,
+			AverageRating: scale.Denormalize(entry.AverageRating),
+			ReviewCount:   int32(entry.ReviewCount),
+			WeightedScore: scale.Denormalize(entry.WeightedScore),
+		})
+	}
+
+	return &svc.GetMovieLeaderboardResponse{
+		Entries: entriesPb,
+		Total:   int32(leaderboard.Total),
+	}
+}
@@ -4,25 +4,69 @@ import (
 	"ap2final_review_service/internal/models"
 	"github.com/sorawaslocked/ap2final_protos_gen/base"
 	svc "github.com/sorawaslocked/ap2final_protos_gen/service/review"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"math"
+	"strings"
 )
 
-func ToReviewFromCreateRequest(req *svc.CreateRequest) models.Review {
-	return models.Review{
-		UserID:  req.UserID,
-		MovieID: req.MovieID,
-		Rating:  int(req.Rating),
-		Comment: req.Comment,
+// Ratings travel in the configured scale: the exact value in the *Value
+// fields and a rounded copy in the legacy integer fields.
+
+func ToReviewFromCreateRequest(req *svc.CreateRequest, scale models.RatingScale) (models.Review, error) {
+	rating := float64(req.Rating)
+	if req.RatingValue != nil {
+		rating = *req.RatingValue
+	}
+
+	stars, err := scale.Normalize(rating)
+	if err != nil {
+		return models.Review{}, err
 	}
+
+	aspectRatings, err := toAspectRatings(req.AspectRatings, req.AspectRatingValues, scale)
+	if err != nil {
+		return models.Review{}, err
+	}
+
+	return models.Review{
+		UserID:        req.UserID,
+		MovieID:       req.MovieID,
+		Rating:        stars,
+		Title:         req.Title,
+		AspectRatings: aspectRatings,
+		Comment:       req.Comment,
+	}, nil
 }
 
-func ToReviewUpdateFromUpdateRequest(req *svc.UpdateRequest) (string, models.ReviewUpdateData) {
+func ToReviewUpdateFromUpdateRequest(req *svc.UpdateRequest, scale models.RatingScale) (string, models.ReviewUpdateData, error) {
 	update := models.ReviewUpdateData{}
 
+	var rating *float64
 	if req.Rating != nil {
-		rating := int(*req.Rating)
-		update.Rating = &rating
+		rating = models.Float64Ptr(float64(*req.Rating))
+	}
+	if req.RatingValue != nil {
+		rating = req.RatingValue
+	}
+
+	if rating != nil {
+		stars, err := scale.Normalize(*rating)
+		if err != nil {
+			return "", models.ReviewUpdateData{}, err
+		}
+		update.Rating = &stars
+	}
+
+	if req.Title != nil {
+		update.Title = req.Title
+	}
+
+	aspectRatings, err := toAspectRatings(req.AspectRatings, req.AspectRatingValues, scale)
+	if err != nil {
+		return "", models.ReviewUpdateData{}, err
 	}
+	update.AspectRatings = aspectRatings
 
 	if req.Comment != nil {
 		update.Comment = req.Comment
@@ -32,18 +76,142 @@ func ToReviewUpdateFromUpdateRequest(req *svc.UpdateRequest) (string, models.Rev
 		update.IsDeleted = req.IsDeleted
 	}
 
-	return req.ID, update
+	return req.ID, update, nil
 }
 
-func FromReviewToPb(review models.Review) *base.Review {
+// ToUpdateMask maps a field mask over UpdateRequest to review paths, which
+// the use case validates. The *_value fields name the same paths as their
+// legacy counterparts. Without a mask the update is not masked.
+func ToUpdateMask(mask *fieldmaskpb.FieldMask) []string {
+	if mask == nil {
+		return nil
+	}
+
+	paths := make([]string, 0, len(mask.GetPaths()))
+	for _, path := range mask.GetPaths() {
+		switch {
+		case path == "rating_value":
+			path = models.UpdatePathRating
+		case path == "aspect_rating_values":
+			path = models.UpdatePathAspectRatings
+		case strings.HasPrefix(path, "aspect_rating_values."):
+			path = models.UpdatePathAspectRatings + strings.TrimPrefix(path, "aspect_rating_values")
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+func FromReviewToPb(review models.Review, scale models.RatingScale) *base.Review {
+	rating := scale.Denormalize(review.Rating)
+	aspectRatings, aspectRatingValues := fromAspectRatings(review.AspectRatings, scale)
+
 	return &base.Review{
-		ID:        review.ID,
-		UserID:    review.UserID,
-		MovieID:   review.MovieID,
-		Rating:    int32(review.Rating),
-		Comment:   review.Comment,
-		CreatedAt: timestamppb.New(review.CreatedAt),
-		UpdatedAt: timestamppb.New(review.UpdatedAt),
-		IsDeleted: review.IsDeleted,
+		ID:                 review.ID,
+		UserID:             review.UserID,
+		MovieID:            review.MovieID,
+		Rating:             int32(math.Round(rating)),
+		RatingValue:        rating,
+		Title:              review.Title,
+		AspectRatings:      aspectRatings,
+		AspectRatingValues: aspectRatingValues,
+		Comment:            review.Comment,
+		CreatedAt:          timestamppb.New(review.CreatedAt),
+		UpdatedAt:          timestamppb.New(review.UpdatedAt),
+		IsDeleted:          review.IsDeleted,
+		Version:            review.Version,
+	}
+}
+
+func FromRatingSummaryToPb(summary models.RatingSummary, scale models.RatingScale) *svc.GetRatingSummaryResponse {
+	aspectAverages := make(map[string]float64, len(summary.AspectAverages))
+	for aspect, average := range summary.AspectAverages {
+		aspectAverages[aspect] = scale.Denormalize(average)
 	}
+
+	return &svc.GetRatingSummaryResponse{
+		MovieID:        summary.MovieID,
+		AverageRating:  scale.Denormalize(summary.AverageRating),
+		ReviewCount:    int32(summary.ReviewCount),
+		WeightedScore:  scale.Denormalize(summary.WeightedScore),
+		AspectAverages: aspectAverages,
+	}
+}
+
+func FromRatingSummaryToMovieRatingPb(summary models.RatingSummary, scale models.RatingScale) *svc.MovieRating {
+	return &svc.MovieRating{
+		MovieID:       summary.MovieID,
+		AverageRating: scale.Denormalize(summary.AverageRating),
+		ReviewCount:   int32(summary.ReviewCount),
+		WeightedScore: scale.Denormalize(summary.WeightedScore),
+	}
+}
+
+func toAspectRatings(
+	ratings map[string]int32,
+	values map[string]float64,
+	scale models.RatingScale,
+) (map[string]float64, error) {
+	if len(ratings) == 0 && len(values) == 0 {
+		return nil, nil
+	}
+
+	res := make(map[string]float64, len(ratings)+len(values))
+	for aspect, rating := range ratings {
+		res[aspect] = float64(rating)
+	}
+	for aspect, value := range values {
+		res[aspect] = value
+	}
+
+	for aspect, rating := range res {
+		stars, err := scale.Normalize(rating)
+		if err != nil {
+			return nil, models.ErrInvalidAspectRating
+		}
+		res[aspect] = stars
+	}
+
+	return res, nil
+}
+
+func fromAspectRatings(ratings map[string]float64, scale models.RatingScale) (map[string]int32, map[string]float64) {
+	if len(ratings) == 0 {
+		return nil, nil
+	}
+
+	rounded := make(map[string]int32, len(ratings))
+	values := make(map[string]float64, len(ratings))
+	for aspect, stars := range ratings {
+		value := scale.Denormalize(stars)
+		rounded[aspect] = int32(math.Round(value))
+		values[aspect] = value
+	}
+
+	return rounded, values
+}
+
+func ToReviewFilterFromStreamRequest(req *svc.StreamReviewsRequest, scale models.RatingScale) models.ReviewFilter {
+	filter := models.ReviewFilter{
+		IDs:       req.IDs,
+		UserID:    req.UserID,
+		MovieID:   req.MovieID,
+		IsDeleted: req.IsDeleted,
+	}
+
+	if req.Rating != nil {
+		filter.Rating = models.Float64Ptr(scale.ToStars(*req.Rating))
+	}
+
+	if req.MinRating != nil {
+		filter.MinRating = models.Float64Ptr(scale.ToStars(*req.MinRating))
+	}
+
+	if req.MaxRating != nil {
+		filter.MaxRating = models.Float64Ptr(scale.ToStars(*req.MaxRating))
+	}
+
+	return filter
 }
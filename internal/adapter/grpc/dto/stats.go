@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"ap2final_review_service/internal/models"
+	svc "github.com/sorawaslocked/ap2final_protos_gen/service/review"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"strconv"
+)
+
+func FromUserReviewStatsToPb(stats models.UserReviewStats, scale models.RatingScale) *svc.GetUserReviewStatsResponse {
+	distribution := make(map[string]int32, len(stats.Distribution))
+	for stars, count := range stats.Distribution {
+		rating := strconv.FormatFloat(scale.Denormalize(stars), 'f', -1, 64)
+		distribution[rating] += int32(count)
+	}
+
+	res := &svc.GetUserReviewStatsResponse{
+		UserID:        stats.UserID,
+		ReviewCount:   int32(stats.ReviewCount),
+		AverageRating: scale.Denormalize(stats.AverageRating),
+		Distribution:  distribution,
+		Monthly:       FromRatingBucketsToPb(stats.Monthly, scale),
+	}
+
+	if stats.ReviewCount > 0 {
+		res.FirstReviewAt = timestamppb.New(stats.FirstReviewAt)
+		res.LastReviewAt = timestamppb.New(stats.LastReviewAt)
+	}
+
+	return res
+}
+
+func FromRatingBucketsToPb(buckets []models.RatingBucket, scale models.RatingScale) []*svc.RatingBucket {
+	bucketsPb := make([]*svc.RatingBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		bucketsPb = append(bucketsPb, &svc.RatingBucket{
+			Start:         timestamppb.New(bucket.Start),
+			ReviewCount:   int32(bucket.ReviewCount),
+			AverageRating: scale.Denormalize(bucket.AverageRating),
+		})
+	}
+
+	return bucketsPb
+}
+
+func ToTimelineQueryFromRequest(req *svc.GetMovieRatingTimelineRequest) models.TimelineQuery {
+	query := models.TimelineQuery{
+		MovieID:    req.MovieID,
+		BucketSize: models.BucketSize(req.BucketSize),
+	}
+
+	if req.From != nil {
+		query.From = req.From.AsTime()
+	}
+
+	if req.To != nil {
+		query.To = req.To.AsTime()
+	}
+
+	return query
+}
@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	svc "github.com/sorawaslocked/ap2final_protos_gen/service/review"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"log/slog"
+	"time"
+)
+
+// Pinger checks that a dependency the service cannot work without is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Used in place of a non-positive health interval or timeout, which would
+// make the ticker panic or every ping fail.
+const (
+	defaultHealthInterval = 10 * time.Second
+	defaultHealthTimeout  = 2 * time.Second
+)
+
+// watchHealth pings the database every interval and reports the result
+// through the grpc health service until ctx is done. The service stays
+// NOT_SERVING until the first ping succeeds.
+func (s *Server) watchHealth(ctx context.Context) {
+	interval, timeout := s.healthCfg.Interval, s.healthCfg.Timeout
+
+	if interval <= 0 {
+		s.log.Warn("invalid health interval, using the default", slog.Duration("interval", defaultHealthInterval))
+		interval = defaultHealthInterval
+	}
+
+	if timeout <= 0 {
+		s.log.Warn("invalid health timeout, using the default", slog.Duration("timeout", defaultHealthTimeout))
+		timeout = defaultHealthTimeout
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	current := healthpb.HealthCheckResponse_NOT_SERVING
+	checked := false
+
+	for {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := s.pinger.Ping(pingCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		next := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			next = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		if !checked || next != current {
+			if err != nil {
+				s.log.Error("database ping failed, reporting not serving", slog.String("error", err.Error()))
+			} else {
+				s.log.Info("database ping succeeded, reporting serving")
+			}
+
+			s.setServingStatus(next)
+			current = next
+			checked = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setServingStatus reports the status of the whole server and of the review
+// service, which probes may check by name.
+func (s *Server) setServingStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(svc.ReviewService_ServiceDesc.ServiceName, status)
+}
@@ -0,0 +1,158 @@
+package grpc
+
+import (
+	"ap2final_review_service/internal/adapter/grpc/dto"
+	"ap2final_review_service/internal/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/sorawaslocked/ap2final_base/pkg/security"
+	"github.com/sorawaslocked/ap2final_protos_gen/base"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"slices"
+)
+
+const (
+	idempotencyKeyHeader     = "idempotency-key"
+	idempotentReplayedHeader = "idempotent-replayed"
+)
+
+// reviewResponse is implemented by the responses of the idempotent methods.
+type reviewResponse interface {
+	GetReview() *base.Review
+}
+
+// errNotProtoMessage is only returned for handlers outside the generated
+// services, which the interceptor is never configured with.
+var errNotProtoMessage = errors.New("idempotent request or response is not a proto message")
+
+// IdempotencyUnaryInterceptor runs calls to methods that carry the
+// idempotency-key metadata at most once per caller and key, and replays
+// the stored response to repeated calls. Calls without the key are not
+// affected. Unexpected failures are returned as is, for the error sanitizer
+// to log.
+func IdempotencyUnaryInterceptor(
+	uc IdempotencyUseCase,
+	jwtProvider *security.JWTProvider,
+	methods []string,
+) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !slices.Contains(methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		values := metadata.ValueFromIncomingContext(ctx, idempotencyKeyHeader)
+		if len(values) == 0 {
+			return handler(ctx, req)
+		}
+
+		if !validMetadataValue(values[0]) {
+			return nil, dto.FromError(models.ErrInvalidIdempotencyKey)
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return nil, errNotProtoMessage
+		}
+
+		requestHash, err := hashRequest(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		// Keys are scoped to the user, or to the peer ip of anonymous
+		// calls, and to the method, so that callers cannot replay each
+		// other's responses or collide across methods.
+		userID := tokenUserID(ctx, jwtProvider)
+		key := info.FullMethod + "|" + callerKey(ctx, userID) + "|" + values[0]
+
+		response, replayed, err := uc.Execute(ctx, key, requestHash, userID, func(ctx context.Context) ([]byte, string, error) {
+			resp, err := handler(ctx, req)
+			if err != nil {
+				return nil, "", err
+			}
+
+			var subjectID string
+			if r, ok := resp.(reviewResponse); ok && r.GetReview() != nil {
+				subjectID = r.GetReview().UserID
+			}
+
+			b, err := marshalResponse(resp)
+
+			return b, subjectID, err
+		})
+		if err != nil {
+			if _, isStatus := status.FromError(err); isStatus {
+				return nil, err
+			}
+			if mapped := dto.FromError(err); status.Code(mapped) != codes.Internal {
+				return nil, mapped
+			}
+			return nil, err
+		}
+
+		resp, err := unmarshalResponse(response)
+		if err != nil {
+			return nil, err
+		}
+
+		// The handler set the headers of the first call, a replay sets them
+		// from the stored response.
+		if replayed {
+			header := metadata.Pairs(idempotentReplayedHeader, "true")
+			if r, ok := resp.(reviewResponse); ok && r.GetReview() != nil {
+				header.Append(etagHeader, models.ETag(r.GetReview().Version))
+			}
+			_ = grpc.SetHeader(ctx, header)
+		}
+
+		return resp, nil
+	}
+}
+
+func hashRequest(req proto.Message) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// marshalResponse stores the response as an Any, so that it can be decoded
+// without knowing the method it belongs to.
+func marshalResponse(resp interface{}) ([]byte, error) {
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		return nil, errNotProtoMessage
+	}
+
+	wrapped, err := anypb.New(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(wrapped)
+}
+
+func unmarshalResponse(b []byte) (interface{}, error) {
+	var wrapped anypb.Any
+	if err := proto.Unmarshal(b, &wrapped); err != nil {
+		return nil, err
+	}
+
+	return wrapped.UnmarshalNew()
+}
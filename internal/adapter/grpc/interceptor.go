@@ -0,0 +1,93 @@
+package grpc
+
+import (
+	"context"
+	"github.com/sorawaslocked/ap2final_base/pkg/security"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"slices"
+	"time"
+)
+
+// AdminInterceptor only lets requests with a valid token carrying adminRole
+// through to adminMethods. Other methods are not checked. Without a JWT
+// provider the admin methods are unavailable.
+func AdminInterceptor(jwtProvider *security.JWTProvider, adminRole string, adminMethods []string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !slices.Contains(adminMethods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		if jwtProvider == nil {
+			return nil, status.Error(codes.PermissionDenied, "admin access is not configured")
+		}
+
+		tokenStr, ok := security.TokenFromCtx(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing authorization header")
+		}
+
+		claims, err := jwtProvider.VerifyAndParseClaims(tokenStr)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		if claims.Role == nil || *claims.Role != adminRole {
+			return nil, status.Error(codes.PermissionDenied, "admin role required")
+		}
+
+		if claims.UserID != nil {
+			ctx = context.WithValue(ctx, adminIDKey{}, *claims.UserID)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+type adminIDKey struct{}
+
+// adminIDFromContext returns the user id of the admin calling an admin-only method.
+func adminIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(adminIDKey{}).(string)
+	return id
+}
+
+// MetricsUnaryInterceptor records the status code and latency of every unary call.
+func MetricsUnaryInterceptor(observer RPCObserver) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+		observer.ObserveRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+
+		return resp, err
+	}
+}
+
+// MetricsStreamInterceptor records the status code and lifetime of every stream.
+func MetricsStreamInterceptor(observer RPCObserver) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+		observer.ObserveRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+
+		return err
+	}
+}
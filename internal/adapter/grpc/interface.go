@@ -3,15 +3,44 @@ package grpc
 import (
 	"ap2final_review_service/internal/models"
 	"context"
+	"time"
 )
 
 type ReviewUseCase interface {
 	Create(ctx context.Context, review models.Review) (models.Review, error)
 	GetByID(ctx context.Context, id string) (models.Review, error)
+	BatchGet(ctx context.Context, ids []string) ([]models.Review, []string, error)
 	GetAll(ctx context.Context) ([]models.Review, error)
+	StreamReviews(ctx context.Context, filter models.ReviewFilter, fn func(models.Review) error) error
 	GetByUserID(ctx context.Context, userID string) ([]models.Review, error)
 	GetByMovieID(ctx context.Context, movieID string) ([]models.Review, error)
 	UpdateByID(ctx context.Context, id string, update models.ReviewUpdateData) (models.Review, error)
-	DeleteByID(ctx context.Context, id string) (models.Review, error)
-	GetMovieAverageRating(ctx context.Context, movieID string) (float64, error)
+	DeleteByID(ctx context.Context, id string, expectedVersion *int64) (models.Review, error)
+	GetMovieAverageRating(ctx context.Context, movieID string) (models.RatingSummary, error)
+	GetTopRatedMovies(ctx context.Context, limit int) ([]models.RatingSummary, error)
+	GetMovieLeaderboard(ctx context.Context, query models.LeaderboardQuery) (models.Leaderboard, error)
+	GetUserReviewStats(ctx context.Context, userID string) (models.UserReviewStats, error)
+	GetMovieRatingTimeline(ctx context.Context, query models.TimelineQuery) ([]models.RatingBucket, error)
+	SubscribeMovieReviews(ctx context.Context, movieID string) (<-chan models.ReviewEvent, func(), error)
+}
+
+type PrivacyUseCase interface {
+	ExportUserData(ctx context.Context, userID string) (models.UserDataExport, error)
+	EraseUserData(ctx context.Context, userID string, mode models.ErasureMode, requestedBy string) (models.ErasureReceipt, error)
+}
+
+type RPCObserver interface {
+	ObserveRPC(method, code string, duration time.Duration)
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, operation, key string) (bool, time.Duration, error)
+}
+
+type IdempotencyUseCase interface {
+	Execute(
+		ctx context.Context,
+		key, requestHash, userID string,
+		fn func(ctx context.Context) (response []byte, subjectID string, err error),
+	) ([]byte, bool, error)
 }
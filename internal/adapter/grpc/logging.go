@@ -0,0 +1,160 @@
+package grpc
+
+import (
+	"ap2final_review_service/pkg/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/sorawaslocked/ap2final_base/pkg/security"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"log/slog"
+)
+
+const (
+	requestIDHeader        = "x-request-id"
+	maxMetadataValueLength = 128
+)
+
+type requestIDKey struct{}
+
+// RequestLoggerUnaryInterceptor gives every call a request id, taken from the
+// x-request-id metadata or generated, and returns it in the response header.
+// The context of the call carries a logger with the request id, method, peer,
+// trace id and the user id of a valid token.
+func RequestLoggerUnaryInterceptor(log *slog.Logger, jwtProvider *security.JWTProvider) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, requestID := withRequestLogger(ctx, log, jwtProvider, info.FullMethod)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+
+		return handler(ctx, req)
+	}
+}
+
+// RequestLoggerStreamInterceptor is the stream counterpart of RequestLoggerUnaryInterceptor.
+func RequestLoggerStreamInterceptor(log *slog.Logger, jwtProvider *security.JWTProvider) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, requestID := withRequestLogger(ss.Context(), log, jwtProvider, info.FullMethod)
+		_ = ss.SetHeader(metadata.Pairs(requestIDHeader, requestID))
+
+		wrapped := middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = ctx
+
+		return handler(srv, wrapped)
+	}
+}
+
+// LoggingOptions configures the call logging of the LoggingInterceptor
+// adapter: one line per finished call, tagged with its request id.
+func LoggingOptions() []logging.Option {
+	return []logging.Option{
+		logging.WithLogOnEvents(logging.FinishCall),
+		logging.WithFieldsFromContext(func(ctx context.Context) logging.Fields {
+			if requestID := requestIDFromContext(ctx); requestID != "" {
+				return logging.Fields{"request_id", requestID}
+			}
+			return nil
+		}),
+	}
+}
+
+func withRequestLogger(
+	ctx context.Context,
+	log *slog.Logger,
+	jwtProvider *security.JWTProvider,
+	method string,
+) (context.Context, string) {
+	requestID := incomingRequestID(ctx)
+
+	attrs := []any{
+		slog.String("request_id", requestID),
+		slog.String("method", method),
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+
+	if userID := tokenUserID(ctx, jwtProvider); userID != "" {
+		attrs = append(attrs, slog.String("user_id", userID))
+	}
+
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		attrs = append(attrs, slog.String("trace_id", spanCtx.TraceID().String()))
+	}
+
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+
+	return logger.WithContext(ctx, log.With(attrs...)), requestID
+}
+
+// incomingRequestID returns the request id sent by the client, or a new one
+// if it is missing or not a short printable string.
+func incomingRequestID(ctx context.Context) string {
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDHeader); len(values) > 0 && validMetadataValue(values[0]) {
+		return values[0]
+	}
+
+	return newRequestID()
+}
+
+// validMetadataValue reports whether a client-chosen id such as a request id
+// or idempotency key is a short printable string.
+func validMetadataValue(value string) bool {
+	if value == "" || len(value) > maxMetadataValueLength {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if value[i] < 0x21 || value[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// tokenUserID returns the user id of a valid bearer token, if the call has one.
+func tokenUserID(ctx context.Context, jwtProvider *security.JWTProvider) string {
+	if jwtProvider == nil {
+		return ""
+	}
+
+	tokenStr, ok := security.TokenFromCtx(ctx)
+	if !ok {
+		return ""
+	}
+
+	claims, err := jwtProvider.VerifyAndParseClaims(tokenStr)
+	if err != nil || claims.UserID == nil {
+		return ""
+	}
+
+	return *claims.UserID
+}
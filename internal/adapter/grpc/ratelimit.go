@@ -0,0 +1,161 @@
+package grpc
+
+import (
+	"ap2final_review_service/pkg/logger"
+	"context"
+	"github.com/sorawaslocked/ap2final_base/pkg/security"
+	svc "github.com/sorawaslocked/ap2final_protos_gen/service/review"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const retryAfterHeader = "retry-after"
+
+// unlimitedServices are never rate limited. Health probes of kubelets and
+// load balancers come from a few ips, and a throttled probe would take the
+// instance out of service.
+var unlimitedServices = []string{
+	healthpb.Health_ServiceDesc.ServiceName,
+	reflectionpb.ServerReflection_ServiceDesc.ServiceName,
+	reflectionv1alphapb.ServerReflection_ServiceDesc.ServiceName,
+}
+
+// RateLimitUnaryInterceptor rejects calls over the limit of their caller with
+// ResourceExhausted. The caller is the user of a valid token, or else the
+// peer ip. If the limiter fails, the call is let through, and without a
+// limiter every call is.
+func RateLimitUnaryInterceptor(
+	limiter RateLimiter,
+	jwtProvider *security.JWTProvider,
+	log *slog.Logger,
+) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := allow(ctx, limiter, jwtProvider, log, info.FullMethod, grpc.SetHeader); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor is the stream counterpart of RateLimitUnaryInterceptor.
+// A stream is limited once, when it is opened.
+func RateLimitStreamInterceptor(
+	limiter RateLimiter,
+	jwtProvider *security.JWTProvider,
+	log *slog.Logger,
+) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		setHeader := func(_ context.Context, md metadata.MD) error {
+			return ss.SetHeader(md)
+		}
+
+		if err := allow(ss.Context(), limiter, jwtProvider, log, info.FullMethod, setHeader); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func allow(
+	ctx context.Context,
+	limiter RateLimiter,
+	jwtProvider *security.JWTProvider,
+	log *slog.Logger,
+	method string,
+	setHeader func(context.Context, metadata.MD) error,
+) error {
+	if limiter == nil || unlimitedMethod(method) {
+		return nil
+	}
+
+	key := callerKey(ctx, tokenUserID(ctx, jwtProvider))
+
+	ok, retryAfter, err := limiter.Allow(ctx, rateLimitOperation(method), key)
+	if err != nil {
+		logger.FromContext(ctx, log).Error("rate limiter failed", slog.String("error", err.Error()))
+		return nil
+	}
+	if ok {
+		return nil
+	}
+
+	// Whole seconds like the http Retry-After header, rounded up so that
+	// clients do not retry too early.
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	_ = setHeader(ctx, metadata.Pairs(retryAfterHeader, strconv.FormatInt(seconds, 10)))
+
+	st, detailErr := status.New(codes.ResourceExhausted, "rate limit exceeded").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Duration(seconds) * time.Second)})
+	if detailErr != nil {
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	return st.Err()
+}
+
+func unlimitedMethod(fullMethod string) bool {
+	for _, service := range unlimitedServices {
+		if strings.HasPrefix(fullMethod, "/"+service+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitOperation names a call for the limiter. Review service methods go
+// by their short name, such as Create, which the configured limits and the
+// http routes use. Methods of other services keep the full method, so that
+// methods of the same name do not share limits.
+func rateLimitOperation(fullMethod string) string {
+	if operation, ok := strings.CutPrefix(fullMethod, "/"+svc.ReviewService_ServiceDesc.ServiceName+"/"); ok {
+		return operation
+	}
+
+	return fullMethod
+}
+
+// callerKey identifies the caller by the user id of its token, or by peer ip
+// for calls without a valid token.
+func callerKey(ctx context.Context, userID string) string {
+	if userID != "" {
+		return "user:" + userID
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "ip:unknown"
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "ip:" + p.Addr.String()
+	}
+
+	return "ip:" + host
+}
@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	svc "github.com/sorawaslocked/ap2final_protos_gen/service/review"
+	"testing"
+)
+
+func TestRateLimitOperation(t *testing.T) {
+	tests := []struct {
+		fullMethod string
+		operation  string
+		unlimited  bool
+	}{
+		{fullMethod: svc.ReviewService_Create_FullMethodName, operation: "Create"},
+		{fullMethod: svc.ReviewService_GetAll_FullMethodName, operation: "GetAll"},
+		{fullMethod: "/other.v1.ReviewService/Create", operation: "/other.v1.ReviewService/Create"},
+		{fullMethod: "/grpc.health.v1.Health/Check", operation: "/grpc.health.v1.Health/Check", unlimited: true},
+		{fullMethod: "/grpc.health.v1.Health/Watch", operation: "/grpc.health.v1.Health/Watch", unlimited: true},
+		{
+			fullMethod: "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+			operation:  "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+			unlimited:  true,
+		},
+		{
+			fullMethod: "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+			operation:  "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+			unlimited:  true,
+		},
+		{fullMethod: "/grpc.health.v1.HealthCheck/Check", operation: "/grpc.health.v1.HealthCheck/Check"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fullMethod, func(t *testing.T) {
+			if got := rateLimitOperation(tt.fullMethod); got != tt.operation {
+				t.Errorf("rateLimitOperation: got %q, want %q", got, tt.operation)
+			}
+			if got := unlimitedMethod(tt.fullMethod); got != tt.unlimited {
+				t.Errorf("unlimitedMethod: got %t, want %t", got, tt.unlimited)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"ap2final_review_service/pkg/logger"
+	"context"
+	"errors"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"runtime/debug"
+)
+
+var errInternal = status.Error(codes.Internal, "internal server error")
+
+// RecoveryUnaryInterceptor turns a panic in a handler into codes.Internal
+// instead of crashing the process. The panic and its stack are logged.
+func RecoveryUnaryInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return recovery.UnaryServerInterceptor(recovery.WithRecoveryHandlerContext(recoverPanic(log)))
+}
+
+// RecoveryStreamInterceptor is the stream counterpart of RecoveryUnaryInterceptor.
+func RecoveryStreamInterceptor(log *slog.Logger) grpc.StreamServerInterceptor {
+	return recovery.StreamServerInterceptor(recovery.WithRecoveryHandlerContext(recoverPanic(log)))
+}
+
+func recoverPanic(log *slog.Logger) recovery.RecoveryHandlerFuncContext {
+	return func(ctx context.Context, p any) error {
+		logger.FromContext(ctx, log).Error("recovered from panic in grpc handler",
+			slog.Any("panic", p),
+			slog.String("stack", string(debug.Stack())),
+		)
+
+		return errInternal
+	}
+}
+
+// ErrorSanitizerUnaryInterceptor makes sure clients only see grpc status
+// errors. Any other error, such as a raw Mongo error a handler failed to
+// map, is logged and replaced by codes.Internal.
+func ErrorSanitizerUnaryInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, sanitizeError(ctx, log, err)
+		}
+
+		return resp, nil
+	}
+}
+
+// ErrorSanitizerStreamInterceptor is the stream counterpart of ErrorSanitizerUnaryInterceptor.
+func ErrorSanitizerStreamInterceptor(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := handler(srv, ss); err != nil {
+			return sanitizeError(ss.Context(), log, err)
+		}
+
+		return nil
+	}
+}
+
+func sanitizeError(ctx context.Context, log *slog.Logger, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	logger.FromContext(ctx, log).Error("unmapped error returned to grpc client", slog.String("error", err.Error()))
+
+	return errInternal
+}
@@ -1,65 +1,106 @@
 package grpc
 
 import (
+	"ap2final_review_service/internal/adapter/archive"
 	"ap2final_review_service/internal/adapter/grpc/dto"
+	"ap2final_review_service/internal/models"
+	"ap2final_review_service/pkg/logger"
 	"context"
 	"github.com/sorawaslocked/ap2final_protos_gen/base"
 	svc "github.com/sorawaslocked/ap2final_protos_gen/service/review"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"log/slog"
 )
 
+const etagHeader = "etag"
+
 type ReviewServer struct {
-	uc  ReviewUseCase
-	log *slog.Logger
+	uc        ReviewUseCase
+	privacyUC PrivacyUseCase
+	scale     models.RatingScale
+	log       *slog.Logger
 	svc.UnimplementedReviewServiceServer
 }
 
 func NewReviewServer(
 	uc ReviewUseCase,
+	privacyUC PrivacyUseCase,
+	scale models.RatingScale,
 	log *slog.Logger,
 ) *ReviewServer {
 	return &ReviewServer{
-		uc:  uc,
-		log: log,
+		uc:        uc,
+		privacyUC: privacyUC,
+		scale:     scale,
+		log:       log,
 	}
 }
 
 func (s *ReviewServer) Create(ctx context.Context, req *svc.CreateRequest) (*svc.CreateResponse, error) {
-	review := dto.ToReviewFromCreateRequest(req)
+	review, err := dto.ToReviewFromCreateRequest(req, s.scale)
+	if err != nil {
+		return nil, dto.FromError(err)
+	}
 
 	createdReview, err := s.uc.Create(ctx, review)
 	if err != nil {
-		s.logError("create", err)
+		s.logError(ctx, "create", err)
 		return nil, dto.FromError(err)
 	}
 
+	setETag(ctx, createdReview)
+
 	return &svc.CreateResponse{
-		Review: dto.FromReviewToPb(createdReview),
+		Review: dto.FromReviewToPb(createdReview, s.scale),
 	}, nil
 }
 
 func (s *ReviewServer) Get(ctx context.Context, req *svc.GetRequest) (*svc.GetResponse, error) {
 	review, err := s.uc.GetByID(ctx, req.ID)
 	if err != nil {
-		s.logError("get", err)
+		s.logError(ctx, "get", err)
 		return nil, dto.FromError(err)
 	}
 
+	setETag(ctx, review)
+
 	return &svc.GetResponse{
-		Review: dto.FromReviewToPb(review),
+		Review: dto.FromReviewToPb(review, s.scale),
+	}, nil
+}
+
+func (s *ReviewServer) BatchGet(ctx context.Context, req *svc.BatchGetRequest) (*svc.BatchGetResponse, error) {
+	reviews, notFoundIDs, err := s.uc.BatchGet(ctx, req.IDs)
+	if err != nil {
+		s.logError(ctx, "batch get", err)
+		return nil, dto.FromError(err)
+	}
+
+	var reviewsPb []*base.Review
+	for _, review := range reviews {
+		reviewsPb = append(reviewsPb, dto.FromReviewToPb(review, s.scale))
+	}
+
+	return &svc.BatchGetResponse{
+		Reviews:     reviewsPb,
+		NotFoundIDs: notFoundIDs,
 	}, nil
 }
 
 func (s *ReviewServer) GetAll(ctx context.Context, req *svc.GetAllRequest) (*svc.GetAllResponse, error) {
 	reviews, err := s.uc.GetAll(ctx)
 	if err != nil {
-		s.logError("get all", err)
+		s.logError(ctx, "get all", err)
 		return nil, dto.FromError(err)
 	}
 
 	var reviewsPb []*base.Review
 	for _, review := range reviews {
-		reviewsPb = append(reviewsPb, dto.FromReviewToPb(review))
+		reviewsPb = append(reviewsPb, dto.FromReviewToPb(review, s.scale))
 	}
 
 	return &svc.GetAllResponse{
@@ -67,16 +108,64 @@ func (s *ReviewServer) GetAll(ctx context.Context, req *svc.GetAllRequest) (*svc
 	}, nil
 }
 
+func (s *ReviewServer) StreamReviews(req *svc.StreamReviewsRequest, stream svc.ReviewService_StreamReviewsServer) error {
+	filter := dto.ToReviewFilterFromStreamRequest(req, s.scale)
+
+	// Send blocks while the client is not reading, which stops the
+	// repository from fetching further batches.
+	err := s.uc.StreamReviews(stream.Context(), filter, func(review models.Review) error {
+		return stream.Send(&svc.StreamReviewsResponse{
+			Review: dto.FromReviewToPb(review, s.scale),
+		})
+	})
+	if err != nil {
+		if stream.Context().Err() != nil {
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+
+		s.logError(stream.Context(), "stream reviews", err)
+		return dto.FromError(err)
+	}
+
+	return nil
+}
+
+func (s *ReviewServer) SubscribeMovieReviews(req *svc.SubscribeMovieReviewsRequest, stream svc.ReviewService_SubscribeMovieReviewsServer) error {
+	ctx := stream.Context()
+
+	events, unsubscribe, err := s.uc.SubscribeMovieReviews(ctx, req.MovieID)
+	if err != nil {
+		s.logError(ctx, "subscribe movie reviews", err)
+		return dto.FromError(err)
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "subscription closed, resubscribe to continue")
+			}
+
+			if err := stream.Send(dto.FromReviewEventToPb(event, s.scale)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (s *ReviewServer) GetByUser(ctx context.Context, req *svc.GetByUserRequest) (*svc.GetByUserResponse, error) {
 	reviews, err := s.uc.GetByUserID(ctx, req.UserID)
 	if err != nil {
-		s.logError("get by user", err)
+		s.logError(ctx, "get by user", err)
 		return nil, dto.FromError(err)
 	}
 
 	var reviewsPb []*base.Review
 	for _, review := range reviews {
-		reviewsPb = append(reviewsPb, dto.FromReviewToPb(review))
+		reviewsPb = append(reviewsPb, dto.FromReviewToPb(review, s.scale))
 	}
 
 	return &svc.GetByUserResponse{
@@ -87,13 +176,13 @@ func (s *ReviewServer) GetByUser(ctx context.Context, req *svc.GetByUserRequest)
 func (s *ReviewServer) GetByMovie(ctx context.Context, req *svc.GetByMovieRequest) (*svc.GetByMovieResponse, error) {
 	reviews, err := s.uc.GetByMovieID(ctx, req.MovieID)
 	if err != nil {
-		s.logError("get by movie", err)
+		s.logError(ctx, "get by movie", err)
 		return nil, dto.FromError(err)
 	}
 
 	var reviewsPb []*base.Review
 	for _, review := range reviews {
-		reviewsPb = append(reviewsPb, dto.FromReviewToPb(review))
+		reviewsPb = append(reviewsPb, dto.FromReviewToPb(review, s.scale))
 	}
 
 	return &svc.GetByMovieResponse{
@@ -102,31 +191,143 @@ func (s *ReviewServer) GetByMovie(ctx context.Context, req *svc.GetByMovieReques
 }
 
 func (s *ReviewServer) Update(ctx context.Context, req *svc.UpdateRequest) (*svc.UpdateResponse, error) {
-	id, update := dto.ToReviewUpdateFromUpdateRequest(req)
+	id, update, err := dto.ToReviewUpdateFromUpdateRequest(req, s.scale)
+	if err != nil {
+		return nil, dto.FromError(err)
+	}
+
+	update.Mask = dto.ToUpdateMask(req.GetUpdateMask())
+
+	update.ExpectedVersion = req.ExpectedVersion
 
 	updatedReview, err := s.uc.UpdateByID(ctx, id, update)
 	if err != nil {
-		s.logError("update", err)
+		s.logError(ctx, "update", err)
 		return nil, dto.FromError(err)
 	}
 
+	setETag(ctx, updatedReview)
+
 	return &svc.UpdateResponse{
-		Review: dto.FromReviewToPb(updatedReview),
+		Review: dto.FromReviewToPb(updatedReview, s.scale),
 	}, nil
 }
 
 func (s *ReviewServer) Delete(ctx context.Context, req *svc.DeleteRequest) (*svc.DeleteResponse, error) {
-	deletedReview, err := s.uc.DeleteByID(ctx, req.ID)
+	deletedReview, err := s.uc.DeleteByID(ctx, req.ID, req.ExpectedVersion)
 	if err != nil {
-		s.logError("delete", err)
+		s.logError(ctx, "delete", err)
 		return nil, dto.FromError(err)
 	}
 
+	setETag(ctx, deletedReview)
+
 	return &svc.DeleteResponse{
-		Review: dto.FromReviewToPb(deletedReview),
+		Review: dto.FromReviewToPb(deletedReview, s.scale),
 	}, nil
 }
 
-func (s *ReviewServer) logError(op string, err error) {
-	s.log.Error("review operation failed", slog.String("operation", op), slog.String("error", err.Error()))
+func (s *ReviewServer) GetRatingSummary(ctx context.Context, req *svc.GetRatingSummaryRequest) (*svc.GetRatingSummaryResponse, error) {
+	summary, err := s.uc.GetMovieAverageRating(ctx, req.MovieID)
+	if err != nil {
+		s.logError(ctx, "get rating summary", err)
+		return nil, dto.FromError(err)
+	}
+
+	return dto.FromRatingSummaryToPb(summary, s.scale), nil
+}
+
+func (s *ReviewServer) GetTopRatedMovies(ctx context.Context, req *svc.GetTopRatedMoviesRequest) (*svc.GetTopRatedMoviesResponse, error) {
+	summaries, err := s.uc.GetTopRatedMovies(ctx, int(req.Limit))
+	if err != nil {
+		s.logError(ctx, "get top rated movies", err)
+		return nil, dto.FromError(err)
+	}
+
+	var moviesPb []*svc.MovieRating
+	for _, summary := range summaries {
+		moviesPb = append(moviesPb, dto.FromRatingSummaryToMovieRatingPb(summary, s.scale))
+	}
+
+	return &svc.GetTopRatedMoviesResponse{
+		Movies: moviesPb,
+	}, nil
+}
+
+func (s *ReviewServer) GetMovieLeaderboard(ctx context.Context, req *svc.GetMovieLeaderboardRequest) (*svc.GetMovieLeaderboardResponse, error) {
+	leaderboard, err := s.uc.GetMovieLeaderboard(ctx, dto.ToLeaderboardQueryFromRequest(req))
+	if err != nil {
+		s.logError(ctx, "get movie leaderboard", err)
+		return nil, dto.FromError(err)
+	}
+
+	return dto.FromLeaderboardToPb(leaderboard, s.scale), nil
+}
+
+func (s *ReviewServer) GetUserReviewStats(ctx context.Context, req *svc.GetUserReviewStatsRequest) (*svc.GetUserReviewStatsResponse, error) {
+	stats, err := s.uc.GetUserReviewStats(ctx, req.UserID)
+	if err != nil {
+		s.logError(ctx, "get user review stats", err)
+		return nil, dto.FromError(err)
+	}
+
+	return dto.FromUserReviewStatsToPb(stats, s.scale), nil
+}
+
+func (s *ReviewServer) GetMovieRatingTimeline(ctx context.Context, req *svc.GetMovieRatingTimelineRequest) (*svc.GetMovieRatingTimelineResponse, error) {
+	buckets, err := s.uc.GetMovieRatingTimeline(ctx, dto.ToTimelineQueryFromRequest(req))
+	if err != nil {
+		s.logError(ctx, "get movie rating timeline", err)
+		return nil, dto.FromError(err)
+	}
+
+	return &svc.GetMovieRatingTimelineResponse{
+		Buckets: dto.FromRatingBucketsToPb(buckets, s.scale),
+	}, nil
+}
+
+func (s *ReviewServer) ExportUserData(ctx context.Context, req *svc.ExportUserDataRequest) (*svc.ExportUserDataResponse, error) {
+	export, err := s.privacyUC.ExportUserData(ctx, req.UserID)
+	if err != nil {
+		s.logError(ctx, "export user data", err)
+		return nil, dto.FromError(err)
+	}
+
+	data, err := archive.EncodeUserData(export, s.scale)
+	if err != nil {
+		s.logError(ctx, "export user data", err)
+		return nil, dto.FromError(err)
+	}
+
+	return &svc.ExportUserDataResponse{
+		Archive:     data,
+		ContentType: "application/json",
+		GeneratedAt: timestamppb.New(export.GeneratedAt),
+	}, nil
+}
+
+func (s *ReviewServer) EraseUserData(ctx context.Context, req *svc.EraseUserDataRequest) (*svc.EraseUserDataResponse, error) {
+	receipt, err := s.privacyUC.EraseUserData(ctx, req.UserID, models.ErasureMode(req.Mode), adminIDFromContext(ctx))
+	if err != nil {
+		s.logError(ctx, "erase user data", err)
+		return nil, dto.FromError(err)
+	}
+
+	return &svc.EraseUserDataResponse{
+		ReceiptID:   receipt.ID,
+		Mode:        string(receipt.Mode),
+		ReviewCount: int32(receipt.ReviewCount),
+		MovieCount:  int32(receipt.MovieCount),
+		CreatedAt:   timestamppb.New(receipt.CreatedAt),
+	}, nil
+}
+
+// setETag returns the version of the review in the etag header. Clients
+// send it back as expected_version.
+func setETag(ctx context.Context, review models.Review) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(etagHeader, models.ETag(review.Version)))
+}
+
+func (s *ReviewServer) logError(ctx context.Context, op string, err error) {
+	logger.FromContext(ctx, s.log).Error("review operation failed", slog.String("operation", op), slog.String("error", err.Error()))
 }
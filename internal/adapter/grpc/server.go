@@ -1,33 +1,76 @@
 package grpc
 
 import (
+	"ap2final_review_service/internal/config"
+	"ap2final_review_service/internal/models"
+	grpcinterceptor "ap2final_review_service/pkg/grpc"
+	"context"
 	"fmt"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	grpccfg "github.com/sorawaslocked/ap2final_base/pkg/grpc"
+	"github.com/sorawaslocked/ap2final_base/pkg/security"
 	svc "github.com/sorawaslocked/ap2final_protos_gen/service/review"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"log/slog"
 	"net"
 )
 
 type Server struct {
-	s             *grpc.Server
-	cfg           grpccfg.Config
-	addr          string
-	log           *slog.Logger
-	reviewUseCase ReviewUseCase
+	s                  *grpc.Server
+	cfg                grpccfg.Config
+	addr               string
+	log                *slog.Logger
+	reviewUseCase      ReviewUseCase
+	privacyUseCase     PrivacyUseCase
+	ratingScale        models.RatingScale
+	jwtProvider        *security.JWTProvider
+	adminRole          string
+	health             *health.Server
+	pinger             Pinger
+	healthCfg          config.Health
+	stopHealth         context.CancelFunc
+	rpcObserver        RPCObserver
+	rateLimiter        RateLimiter
+	idempotencyUseCase IdempotencyUseCase
 }
 
+// New creates the grpc server. jwtProvider may be nil, which disables the
+// admin-only methods. pinger is checked for the grpc health service and
+// rpcObserver records every call. rateLimiter may be nil, which disables rate
+// limiting. idempotencyUseCase makes Create and Update idempotent for calls
+// with an idempotency key.
 func New(
 	cfg grpccfg.Config,
 	log *slog.Logger,
 	reviewUseCase ReviewUseCase,
+	privacyUseCase PrivacyUseCase,
+	ratingScale models.RatingScale,
+	jwtProvider *security.JWTProvider,
+	adminRole string,
+	pinger Pinger,
+	healthCfg config.Health,
+	rpcObserver RPCObserver,
+	rateLimiter RateLimiter,
+	idempotencyUseCase IdempotencyUseCase,
 ) *Server {
 	server := &Server{
-		cfg:           cfg,
-		addr:          fmt.Sprintf(":%d", cfg.Port),
-		log:           log,
-		reviewUseCase: reviewUseCase,
+		cfg:                cfg,
+		addr:               fmt.Sprintf(":%d", cfg.Port),
+		log:                log,
+		reviewUseCase:      reviewUseCase,
+		privacyUseCase:     privacyUseCase,
+		ratingScale:        ratingScale,
+		jwtProvider:        jwtProvider,
+		adminRole:          adminRole,
+		pinger:             pinger,
+		healthCfg:          healthCfg,
+		rpcObserver:        rpcObserver,
+		rateLimiter:        rateLimiter,
+		idempotencyUseCase: idempotencyUseCase,
 	}
 
 	server.register()
@@ -36,6 +79,11 @@ func New(
 }
 
 func (s *Server) MustRun() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopHealth = cancel
+
+	go s.watchHealth(ctx)
+
 	go func() {
 		if err := s.run(); err != nil {
 			panic(err)
@@ -46,13 +94,57 @@ func (s *Server) MustRun() {
 func (s *Server) Stop() {
 	s.log.Info("stopping grpc server", slog.String("addr", s.addr))
 
+	if s.stopHealth != nil {
+		s.stopHealth()
+	}
+
+	// Sets every service to NOT_SERVING and ignores later updates, so that
+	// probes stop routing traffic here while open calls drain.
+	s.health.Shutdown()
+
 	s.s.GracefulStop()
 }
 
 func (s *Server) register() {
-	s.s = grpc.NewServer()
+	adminMethods := []string{
+		svc.ReviewService_ExportUserData_FullMethodName,
+		svc.ReviewService_EraseUserData_FullMethodName,
+	}
+
+	idempotentMethods := []string{
+		svc.ReviewService_Create_FullMethodName,
+		svc.ReviewService_Update_FullMethodName,
+	}
 
-	svc.RegisterReviewServiceServer(s.s, NewReviewServer(s.reviewUseCase, s.log))
+	s.s = grpc.NewServer(
+		// Continues the trace from the incoming metadata and opens the
+		// server span of every call.
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			MetricsUnaryInterceptor(s.rpcObserver),
+			RequestLoggerUnaryInterceptor(s.log, s.jwtProvider),
+			RecoveryUnaryInterceptor(s.log),
+			ErrorSanitizerUnaryInterceptor(s.log),
+			logging.UnaryServerInterceptor(grpcinterceptor.LoggingInterceptor(s.log), LoggingOptions()...),
+			RateLimitUnaryInterceptor(s.rateLimiter, s.jwtProvider, s.log),
+			AdminInterceptor(s.jwtProvider, s.adminRole, adminMethods),
+			IdempotencyUnaryInterceptor(s.idempotencyUseCase, s.jwtProvider, idempotentMethods),
+		),
+		grpc.ChainStreamInterceptor(
+			MetricsStreamInterceptor(s.rpcObserver),
+			RequestLoggerStreamInterceptor(s.log, s.jwtProvider),
+			RecoveryStreamInterceptor(s.log),
+			ErrorSanitizerStreamInterceptor(s.log),
+			logging.StreamServerInterceptor(grpcinterceptor.LoggingInterceptor(s.log), LoggingOptions()...),
+			RateLimitStreamInterceptor(s.rateLimiter, s.jwtProvider, s.log),
+		),
+	)
+
+	svc.RegisterReviewServiceServer(s.s, NewReviewServer(s.reviewUseCase, s.privacyUseCase, s.ratingScale, s.log))
+
+	s.health = health.NewServer()
+	s.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s.s, s.health)
 
 	reflection.Register(s.s)
 }
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	grpcdto "ap2final_review_service/internal/adapter/grpc/dto"
+	"ap2final_review_service/internal/models"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// ErrorResponse carries the same code and message the grpc api returns for
+// the error, so both apis report failures alike.
+type ErrorResponse struct {
+	Code    int    `json:"code"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// FromError maps err through the grpc error mapping and returns the http
+// status code with the response body. Version conflicts are reported as
+// failed If-Match preconditions.
+func FromError(err error) (int, ErrorResponse) {
+	code, body := FromStatus(status.Convert(grpcdto.FromError(err)))
+
+	if errors.Is(err, models.ErrVersionConflict) {
+		code = http.StatusPreconditionFailed
+	}
+
+	return code, body
+}
+
+func FromStatus(st *status.Status) (int, ErrorResponse) {
+	return httpStatus(st.Code()), ErrorResponse{
+		Code:    int(st.Code()),
+		Status:  st.Code().String(),
+		Message: st.Message(),
+	}
+}
+
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,213 @@
+package dto
+
+import (
+	"ap2final_review_service/internal/models"
+	"strings"
+	"time"
+)
+
+// Ratings are on the configured rating scale, like in the grpc api.
+
+type Review struct {
+	ID            string             `json:"id"`
+	UserID        string             `json:"user_id"`
+	MovieID       string             `json:"movie_id"`
+	Rating        float64            `json:"rating"`
+	Title         string             `json:"title,omitempty"`
+	AspectRatings map[string]float64 `json:"aspect_ratings,omitempty"`
+	Comment       string             `json:"comment"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	IsDeleted     bool               `json:"is_deleted"`
+	Version       int64              `json:"version"`
+}
+
+type CreateReviewRequest struct {
+	UserID        string             `json:"user_id"`
+	MovieID       string             `json:"movie_id"`
+	Rating        float64            `json:"rating"`
+	Title         string             `json:"title,omitempty"`
+	AspectRatings map[string]float64 `json:"aspect_ratings,omitempty"`
+	Comment       string             `json:"comment"`
+}
+
+type UpdateReviewRequest struct {
+	Rating        *float64           `json:"rating"`
+	Title         *string            `json:"title"`
+	AspectRatings map[string]float64 `json:"aspect_ratings,omitempty"`
+	Comment       *string            `json:"comment"`
+	IsDeleted     *bool              `json:"is_deleted"`
+}
+
+type ReviewResponse struct {
+	Review Review `json:"review"`
+}
+
+type ReviewsResponse struct {
+	Reviews     []Review `json:"reviews"`
+	NotFoundIDs []string `json:"not_found_ids,omitempty"`
+}
+
+type RatingSummaryResponse struct {
+	MovieID        string             `json:"movie_id"`
+	AverageRating  float64            `json:"average_rating"`
+	ReviewCount    int                `json:"review_count"`
+	WeightedScore  float64            `json:"weighted_score"`
+	AspectAverages map[string]float64 `json:"aspect_averages,omitempty"`
+}
+
+type MovieRating struct {
+	MovieID       string  `json:"movie_id"`
+	AverageRating float64 `json:"average_rating"`
+	ReviewCount   int     `json:"review_count"`
+	WeightedScore float64 `json:"weighted_score"`
+}
+
+type TopRatedMoviesResponse struct {
+	Movies []MovieRating `json:"movies"`
+}
+
+func ToReviewFromCreateRequest(req CreateReviewRequest, scale models.RatingScale) (models.Review, error) {
+	stars, err := scale.Normalize(req.Rating)
+	if err != nil {
+		return models.Review{}, err
+	}
+
+	aspectRatings, err := toAspectRatings(req.AspectRatings, scale)
+	if err != nil {
+		return models.Review{}, err
+	}
+
+	return models.Review{
+		UserID:        req.UserID,
+		MovieID:       req.MovieID,
+		Rating:        stars,
+		Title:         req.Title,
+		AspectRatings: aspectRatings,
+		Comment:       req.Comment,
+	}, nil
+}
+
+func ToReviewUpdateFromUpdateRequest(req UpdateReviewRequest, scale models.RatingScale) (models.ReviewUpdateData, error) {
+	update := models.ReviewUpdateData{
+		Title:     req.Title,
+		Comment:   req.Comment,
+		IsDeleted: req.IsDeleted,
+	}
+
+	if req.Rating != nil {
+		stars, err := scale.Normalize(*req.Rating)
+		if err != nil {
+			return models.ReviewUpdateData{}, err
+		}
+		update.Rating = &stars
+	}
+
+	aspectRatings, err := toAspectRatings(req.AspectRatings, scale)
+	if err != nil {
+		return models.ReviewUpdateData{}, err
+	}
+	update.AspectRatings = aspectRatings
+
+	return update, nil
+}
+
+// ToUpdateMask reads the comma-separated paths of the update_mask query
+// parameter, named like the request fields. Without it the update is not
+// masked.
+func ToUpdateMask(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+// ToExpectedVersion reads the version of an If-Match header. Without the
+// header, or with *, the request is unconditional.
+func ToExpectedVersion(ifMatch string) (*int64, error) {
+	if ifMatch == "" || ifMatch == "*" {
+		return nil, nil
+	}
+
+	version, err := models.ParseETag(ifMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+func FromReview(review models.Review, scale models.RatingScale) Review {
+	return Review{
+		ID:            review.ID,
+		UserID:        review.UserID,
+		MovieID:       review.MovieID,
+		Rating:        scale.Denormalize(review.Rating),
+		Title:         review.Title,
+		AspectRatings: fromAspectRatings(review.AspectRatings, scale),
+		Comment:       review.Comment,
+		CreatedAt:     review.CreatedAt,
+		UpdatedAt:     review.UpdatedAt,
+		IsDeleted:     review.IsDeleted,
+		Version:       review.Version,
+	}
+}
+
+func FromReviews(reviews []models.Review, scale models.RatingScale) []Review {
+	res := make([]Review, 0, len(reviews))
+	for _, review := range reviews {
+		res = append(res, FromReview(review, scale))
+	}
+
+	return res
+}
+
+func FromRatingSummary(summary models.RatingSummary, scale models.RatingScale) RatingSummaryResponse {
+	return RatingSummaryResponse{
+		MovieID:        summary.MovieID,
+		AverageRating:  scale.Denormalize(summary.AverageRating),
+		ReviewCount:    summary.ReviewCount,
+		WeightedScore:  scale.Denormalize(summary.WeightedScore),
+		AspectAverages: fromAspectRatings(summary.AspectAverages, scale),
+	}
+}
+
+func FromRatingSummaryToMovieRating(summary models.RatingSummary, scale models.RatingScale) MovieRating {
+	return MovieRating{
+		MovieID:       summary.MovieID,
+		AverageRating: scale.Denormalize(summary.AverageRating),
+		ReviewCount:   summary.ReviewCount,
+		WeightedScore: scale.Denormalize(summary.WeightedScore),
+	}
+}
+
+func toAspectRatings(ratings map[string]float64, scale models.RatingScale) (map[string]float64, error) {
+	if len(ratings) == 0 {
+		return nil, nil
+	}
+
+	res := make(map[string]float64, len(ratings))
+	for aspect, rating := range ratings {
+		stars, err := scale.Normalize(rating)
+		if err != nil {
+			return nil, models.ErrInvalidAspectRating
+		}
+		res[aspect] = stars
+	}
+
+	return res, nil
+}
+
+func fromAspectRatings(ratings map[string]float64, scale models.RatingScale) map[string]float64 {
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	res := make(map[string]float64, len(ratings))
+	for aspect, stars := range ratings {
+		res[aspect] = scale.Denormalize(stars)
+	}
+
+	return res
+}
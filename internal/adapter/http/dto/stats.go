@@ -0,0 +1,165 @@
+package dto
+
+import (
+	"ap2final_review_service/internal/models"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type LeaderboardEntry struct {
+	Rank          int     `json:"rank"`
+	MovieID       string  `json:"movie_id"`
+	AverageRating float64 `json:"average_rating"`
+	ReviewCount   int     `json:"review_count"`
+	WeightedScore float64 `json:"weighted_score"`
+}
+
+type LeaderboardResponse struct {
+	Entries []LeaderboardEntry `json:"entries"`
+	Total   int                `json:"total"`
+}
+
+type RatingBucket struct {
+	Start         time.Time `json:"start"`
+	ReviewCount   int       `json:"review_count"`
+	AverageRating float64   `json:"average_rating"`
+}
+
+type TimelineResponse struct {
+	Buckets []RatingBucket `json:"buckets"`
+}
+
+type UserReviewStatsResponse struct {
+	UserID        string         `json:"user_id"`
+	ReviewCount   int            `json:"review_count"`
+	AverageRating float64        `json:"average_rating"`
+	Distribution  map[string]int `json:"distribution"`
+	FirstReviewAt *time.Time     `json:"first_review_at,omitempty"`
+	LastReviewAt  *time.Time     `json:"last_review_at,omitempty"`
+	Monthly       []RatingBucket `json:"monthly"`
+}
+
+// ToLeaderboardQuery reads sort_by, window (a duration such as 720h),
+// min_reviews, limit and offset from the query string.
+func ToLeaderboardQuery(values url.Values) (models.LeaderboardQuery, error) {
+	query := models.LeaderboardQuery{
+		SortBy: models.LeaderboardSort(values.Get("sort_by")),
+	}
+
+	var err error
+
+	if window := values.Get("window"); window != "" {
+		if query.Window, err = time.ParseDuration(window); err != nil {
+			return models.LeaderboardQuery{}, models.ErrInvalidWindow
+		}
+	}
+
+	if query.MinReviews, err = IntParam(values, "min_reviews"); err != nil {
+		return models.LeaderboardQuery{}, err
+	}
+
+	if query.Limit, err = IntParam(values, "limit"); err != nil {
+		return models.LeaderboardQuery{}, err
+	}
+
+	if query.Offset, err = IntParam(values, "offset"); err != nil {
+		return models.LeaderboardQuery{}, err
+	}
+
+	return query, nil
+}
+
+func FromLeaderboard(leaderboard models.Leaderboard, scale models.RatingScale) LeaderboardResponse {
+	entries := make([]LeaderboardEntry, 0, len(leaderboard.Entries))
+	for _, entry := range leaderboard.Entries {
+		entries = append(entries, LeaderboardEntry{
+			Rank:          entry.Rank,
+			MovieID:       entry.MovieID,
+			AverageRating: scale.Denormalize(entry.AverageRating),
+			ReviewCount:   entry.ReviewCount,
+			WeightedScore: scale.Denormalize(entry.WeightedScore),
+		})
+	}
+
+	return LeaderboardResponse{
+		Entries: entries,
+		Total:   leaderboard.Total,
+	}
+}
+
+// ToTimelineQuery reads bucket_size and the RFC 3339 from and to bounds
+// from the query string.
+func ToTimelineQuery(movieID string, values url.Values) (models.TimelineQuery, error) {
+	query := models.TimelineQuery{
+		MovieID:    movieID,
+		BucketSize: models.BucketSize(values.Get("bucket_size")),
+	}
+
+	var err error
+
+	if from := values.Get("from"); from != "" {
+		if query.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return models.TimelineQuery{}, models.ErrInvalidTimeRange
+		}
+	}
+
+	if to := values.Get("to"); to != "" {
+		if query.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return models.TimelineQuery{}, models.ErrInvalidTimeRange
+		}
+	}
+
+	return query, nil
+}
+
+func FromRatingBuckets(buckets []models.RatingBucket, scale models.RatingScale) []RatingBucket {
+	res := make([]RatingBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		res = append(res, RatingBucket{
+			Start:         bucket.Start,
+			ReviewCount:   bucket.ReviewCount,
+			AverageRating: scale.Denormalize(bucket.AverageRating),
+		})
+	}
+
+	return res
+}
+
+func FromUserReviewStats(stats models.UserReviewStats, scale models.RatingScale) UserReviewStatsResponse {
+	distribution := make(map[string]int, len(stats.Distribution))
+	for stars, count := range stats.Distribution {
+		rating := strconv.FormatFloat(scale.Denormalize(stars), 'f', -1, 64)
+		distribution[rating] += count
+	}
+
+	res := UserReviewStatsResponse{
+		UserID:        stats.UserID,
+		ReviewCount:   stats.ReviewCount,
+		AverageRating: scale.Denormalize(stats.AverageRating),
+		Distribution:  distribution,
+		Monthly:       FromRatingBuckets(stats.Monthly, scale),
+	}
+
+	if stats.ReviewCount > 0 {
+		res.FirstReviewAt = &stats.FirstReviewAt
+		res.LastReviewAt = &stats.LastReviewAt
+	}
+
+	return res
+}
+
+// IntParam reads an optional integer query parameter, which is zero when absent.
+func IntParam(values url.Values, name string) (int, error) {
+	value := values.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, models.ErrInvalidInput
+	}
+
+	return n, nil
+}
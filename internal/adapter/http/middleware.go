@@ -0,0 +1,97 @@
+package http
+
+import (
+	"ap2final_review_service/internal/adapter/http/dto"
+	"ap2final_review_service/pkg/logger"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+const (
+	requestIDHeader    = "X-Request-Id"
+	maxRequestIDLength = 128
+)
+
+// requestLogger takes the request id from the X-Request-Id header or
+// generates one, echoes it in the response and stores a logger with the
+// request id, method, path, peer and trace id in the request context.
+func requestLogger(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+
+		attrs := []any{
+			slog.String("request_id", requestID),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("peer", r.RemoteAddr),
+		}
+
+		if spanCtx := trace.SpanContextFromContext(r.Context()); spanCtx.HasTraceID() {
+			attrs = append(attrs, slog.String("trace_id", spanCtx.TraceID().String()))
+		}
+
+		reqLog := log.With(attrs...)
+
+		next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context(), reqLog)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// recoverPanic answers a panicking request with a 500 error body instead of
+// dropping the connection. The panic and its stack are logged.
+func recoverPanic(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			logger.FromContext(r.Context(), log).Error("recovered from panic in http handler",
+				slog.Any("panic", p),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			code, body := dto.FromError(errors.New("panic"))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			_ = json.NewEncoder(w).Encode(body)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
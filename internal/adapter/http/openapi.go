@@ -0,0 +1,236 @@
+package http
+
+import (
+	"ap2final_review_service/internal/adapter/http/dto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+	apiVersion     = "1.0.0"
+)
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+type object = map[string]any
+
+// openAPIDocument describes the given routes. Schemas are derived from the
+// request and response types through their json tags, and every operation
+// lists the dto.ErrorResponse body for its error statuses.
+func openAPIDocument(routes []route) ([]byte, error) {
+	schemas := schemaSet{schemas: object{}}
+	errorRef := schemas.ref(reflect.TypeOf(dto.ErrorResponse{}))
+
+	paths := object{}
+	for _, r := range routes {
+		if r.summary == "" || r.status == 0 || r.response == nil {
+			return nil, fmt.Errorf("route %s %s is not documented", r.method, r.pattern)
+		}
+
+		item, ok := paths[r.pattern].(object)
+		if !ok {
+			item = object{}
+			paths[r.pattern] = item
+		}
+
+		item[strings.ToLower(r.method)] = operation(r, &schemas, errorRef)
+	}
+
+	return json.MarshalIndent(object{
+		"openapi": openAPIVersion,
+		"info": object{
+			"title":       "Review service",
+			"version":     apiVersion,
+			"description": "Ratings are on the rating scale the service is configured with.",
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": schemas.schemas,
+		},
+	}, "", "  ")
+}
+
+func operation(r route, schemas *schemaSet, errorRef object) object {
+	var params []object
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(r.pattern, -1) {
+		params = append(params, object{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   object{"type": "string"},
+		})
+	}
+
+	for _, q := range r.query {
+		params = append(params, parameter(q, "query"))
+	}
+
+	for _, h := range r.header {
+		params = append(params, parameter(h, "header"))
+	}
+
+	errorResponse := func(description string) object {
+		return object{
+			"description": description,
+			"content": object{
+				"application/json": object{"schema": errorRef},
+			},
+		}
+	}
+
+	responses := object{
+		strconv.Itoa(r.status): object{
+			"description": http.StatusText(r.status),
+			"content": object{
+				"application/json": object{"schema": schemas.ref(reflect.TypeOf(r.response))},
+			},
+		},
+		"default": errorResponse("Unexpected error"),
+	}
+	for _, status := range r.errorStatus {
+		responses[strconv.Itoa(status)] = errorResponse(http.StatusText(status))
+	}
+
+	// Any route may be rate limited.
+	responses[strconv.Itoa(http.StatusTooManyRequests)] = errorResponse(http.StatusText(http.StatusTooManyRequests))
+
+	op := object{
+		"summary":   r.summary,
+		"responses": responses,
+	}
+
+	if r.description != "" {
+		op["description"] = r.description
+	}
+
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if r.request != nil {
+		op["requestBody"] = object{
+			"required": true,
+			"content": object{
+				"application/json": object{"schema": schemas.ref(reflect.TypeOf(r.request))},
+			},
+		}
+	}
+
+	return op
+}
+
+func parameter(p param, in string) object {
+	schema := object{"type": p.kind}
+	if p.format != "" {
+		schema["format"] = p.format
+	}
+
+	res := object{
+		"name":   p.name,
+		"in":     in,
+		"schema": schema,
+	}
+	if p.description != "" {
+		res["description"] = p.description
+	}
+
+	return res
+}
+
+// schemaSet collects the component schemas of the named struct types it
+// has been asked to reference.
+type schemaSet struct {
+	schemas object
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (s *schemaSet) ref(t reflect.Type) object {
+	if _, ok := s.schemas[t.Name()]; !ok {
+		// Reserve the name first, so that recursive types terminate.
+		s.schemas[t.Name()] = object{}
+		s.schemas[t.Name()] = s.structSchema(t)
+	}
+
+	return object{"$ref": "#/components/schemas/" + t.Name()}
+}
+
+func (s *schemaSet) structSchema(t reflect.Type) object {
+	properties := object{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.schema(field.Type)
+
+		// Pointers mark optional input fields, omitempty optional output fields.
+		if field.Type.Kind() != reflect.Ptr && !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := object{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func (s *schemaSet) schema(t reflect.Type) object {
+	if t == timeType {
+		return object{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.schema(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return schema
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Struct:
+		return s.ref(t)
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer"}
+	case reflect.Float32:
+		return object{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return object{"type": "number", "format": "double"}
+	default:
+		return object{}
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"ap2final_review_service/internal/config"
+	"ap2final_review_service/internal/models"
+	"bytes"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden OpenAPI document")
+
+const goldenOpenAPI = "openapi.json"
+
+func newTestServer() *Server {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(config.HTTP{}, log, nil, models.DefaultRatingScale, nil, nil)
+}
+
+func serveOpenAPI(t *testing.T, s *Server) []byte {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.s.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	return rec.Body.Bytes()
+}
+
+// TestOpenAPIGolden pins the served document. Run with -update after an
+// intended API change and review the diff of testdata/openapi.json.
+func TestOpenAPIGolden(t *testing.T) {
+	doc := serveOpenAPI(t, newTestServer())
+	path := filepath.Join("testdata", goldenOpenAPI)
+
+	if *update {
+		if err := os.WriteFile(path, doc, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(doc, want) {
+		t.Errorf("OpenAPI document differs from %s, rerun with -update if the change is intended", path)
+	}
+}
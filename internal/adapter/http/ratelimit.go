@@ -0,0 +1,86 @@
+package http
+
+import (
+	"ap2final_review_service/internal/adapter/http/dto"
+	"ap2final_review_service/pkg/logger"
+	"encoding/json"
+	"github.com/sorawaslocked/ap2final_base/pkg/security"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// rateLimit rejects requests over the limit of their caller with 429 and a
+// Retry-After header. It shares the limiter and caller keys of the grpc
+// server, so a caller has one budget per operation across both apis. If the
+// limiter fails, the request is let through, and without a limiter every
+// request is.
+func rateLimit(
+	limiter RateLimiter,
+	jwtProvider *security.JWTProvider,
+	log *slog.Logger,
+	operation string,
+	next http.HandlerFunc,
+) http.HandlerFunc {
+	if limiter == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ok, retryAfter, err := limiter.Allow(r.Context(), operation, rateLimitKey(r, jwtProvider))
+		if err != nil {
+			logger.FromContext(r.Context(), log).Error("rate limiter failed", slog.String("error", err.Error()))
+		}
+		if err != nil || ok {
+			next(w, r)
+			return
+		}
+
+		// Rounded up so that clients do not retry too early.
+		seconds := int64(math.Ceil(retryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+
+		code, body := dto.FromStatus(status.New(codes.ResourceExhausted, "rate limit exceeded"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+// rateLimitKey identifies the caller by the user of a valid bearer token, or
+// else by peer ip, like the grpc server does.
+func rateLimitKey(r *http.Request, jwtProvider *security.JWTProvider) string {
+	if userID := bearerUserID(r, jwtProvider); userID != "" {
+		return "user:" + userID
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func bearerUserID(r *http.Request, jwtProvider *security.JWTProvider) string {
+	if jwtProvider == nil {
+		return ""
+	}
+
+	tokenStr, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	claims, err := jwtProvider.VerifyAndParseClaims(tokenStr)
+	if err != nil || claims.UserID == nil {
+		return ""
+	}
+
+	return *claims.UserID
+}
@@ -0,0 +1,296 @@
+package http
+
+import (
+	"ap2final_review_service/internal/adapter/http/dto"
+	"ap2final_review_service/internal/models"
+	"ap2final_review_service/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const maxBodySize = 1 << 20
+
+type ReviewHandler struct {
+	uc    ReviewUseCase
+	scale models.RatingScale
+	log   *slog.Logger
+}
+
+func NewReviewHandler(uc ReviewUseCase, scale models.RatingScale, log *slog.Logger) *ReviewHandler {
+	return &ReviewHandler{
+		uc:    uc,
+		scale: scale,
+		log:   log,
+	}
+}
+
+func (h *ReviewHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateReviewRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	review, err := dto.ToReviewFromCreateRequest(req, h.scale)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	createdReview, err := h.uc.Create(r.Context(), review)
+	if err != nil {
+		h.logError(r.Context(), "create", err)
+		h.writeError(w, err)
+		return
+	}
+
+	setETag(w, createdReview)
+	h.writeJSON(w, http.StatusCreated, dto.ReviewResponse{
+		Review: dto.FromReview(createdReview, h.scale),
+	})
+}
+
+func (h *ReviewHandler) Get(w http.ResponseWriter, r *http.Request) {
+	review, err := h.uc.GetByID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logError(r.Context(), "get", err)
+		h.writeError(w, err)
+		return
+	}
+
+	setETag(w, review)
+	h.writeJSON(w, http.StatusOK, dto.ReviewResponse{
+		Review: dto.FromReview(review, h.scale),
+	})
+}
+
+// GetAll returns every review, or only the reviews listed in the
+// comma-separated ids query parameter.
+func (h *ReviewHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	if ids := r.URL.Query().Get("ids"); ids != "" {
+		reviews, notFoundIDs, err := h.uc.BatchGet(r.Context(), strings.Split(ids, ","))
+		if err != nil {
+			h.logError(r.Context(), "batch get", err)
+			h.writeError(w, err)
+			return
+		}
+
+		h.writeJSON(w, http.StatusOK, dto.ReviewsResponse{
+			Reviews:     dto.FromReviews(reviews, h.scale),
+			NotFoundIDs: notFoundIDs,
+		})
+		return
+	}
+
+	reviews, err := h.uc.GetAll(r.Context())
+	if err != nil {
+		h.logError(r.Context(), "get all", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, dto.ReviewsResponse{
+		Reviews: dto.FromReviews(reviews, h.scale),
+	})
+}
+
+func (h *ReviewHandler) GetByUser(w http.ResponseWriter, r *http.Request) {
+	reviews, err := h.uc.GetByUserID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logError(r.Context(), "get by user", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, dto.ReviewsResponse{
+		Reviews: dto.FromReviews(reviews, h.scale),
+	})
+}
+
+func (h *ReviewHandler) GetByMovie(w http.ResponseWriter, r *http.Request) {
+	reviews, err := h.uc.GetByMovieID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logError(r.Context(), "get by movie", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, dto.ReviewsResponse{
+		Reviews: dto.FromReviews(reviews, h.scale),
+	})
+}
+
+func (h *ReviewHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateReviewRequest
+	if err := h.decode(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	update, err := dto.ToReviewUpdateFromUpdateRequest(req, h.scale)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	update.Mask = dto.ToUpdateMask(r.URL.Query().Get("update_mask"))
+
+	update.ExpectedVersion, err = dto.ToExpectedVersion(r.Header.Get("If-Match"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	updatedReview, err := h.uc.UpdateByID(r.Context(), r.PathValue("id"), update)
+	if err != nil {
+		h.logError(r.Context(), "update", err)
+		h.writeError(w, err)
+		return
+	}
+
+	setETag(w, updatedReview)
+	h.writeJSON(w, http.StatusOK, dto.ReviewResponse{
+		Review: dto.FromReview(updatedReview, h.scale),
+	})
+}
+
+func (h *ReviewHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	version, err := dto.ToExpectedVersion(r.Header.Get("If-Match"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	deletedReview, err := h.uc.DeleteByID(r.Context(), r.PathValue("id"), version)
+	if err != nil {
+		h.logError(r.Context(), "delete", err)
+		h.writeError(w, err)
+		return
+	}
+
+	setETag(w, deletedReview)
+	h.writeJSON(w, http.StatusOK, dto.ReviewResponse{
+		Review: dto.FromReview(deletedReview, h.scale),
+	})
+}
+
+func (h *ReviewHandler) GetRatingSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.uc.GetMovieAverageRating(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logError(r.Context(), "get rating summary", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, dto.FromRatingSummary(summary, h.scale))
+}
+
+func (h *ReviewHandler) GetTopRatedMovies(w http.ResponseWriter, r *http.Request) {
+	limit, err := dto.IntParam(r.URL.Query(), "limit")
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	summaries, err := h.uc.GetTopRatedMovies(r.Context(), limit)
+	if err != nil {
+		h.logError(r.Context(), "get top rated movies", err)
+		h.writeError(w, err)
+		return
+	}
+
+	movies := make([]dto.MovieRating, 0, len(summaries))
+	for _, summary := range summaries {
+		movies = append(movies, dto.FromRatingSummaryToMovieRating(summary, h.scale))
+	}
+
+	h.writeJSON(w, http.StatusOK, dto.TopRatedMoviesResponse{
+		Movies: movies,
+	})
+}
+
+func (h *ReviewHandler) GetMovieLeaderboard(w http.ResponseWriter, r *http.Request) {
+	query, err := dto.ToLeaderboardQuery(r.URL.Query())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	leaderboard, err := h.uc.GetMovieLeaderboard(r.Context(), query)
+	if err != nil {
+		h.logError(r.Context(), "get movie leaderboard", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, dto.FromLeaderboard(leaderboard, h.scale))
+}
+
+func (h *ReviewHandler) GetUserReviewStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.uc.GetUserReviewStats(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logError(r.Context(), "get user review stats", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, dto.FromUserReviewStats(stats, h.scale))
+}
+
+func (h *ReviewHandler) GetMovieRatingTimeline(w http.ResponseWriter, r *http.Request) {
+	query, err := dto.ToTimelineQuery(r.PathValue("id"), r.URL.Query())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	buckets, err := h.uc.GetMovieRatingTimeline(r.Context(), query)
+	if err != nil {
+		h.logError(r.Context(), "get movie rating timeline", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, dto.TimelineResponse{
+		Buckets: dto.FromRatingBuckets(buckets, h.scale),
+	})
+}
+
+// decode reads a single JSON object from the request body. Unknown fields
+// are rejected, so that misspelt fields are not silently ignored.
+func (h *ReviewHandler) decode(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return errors.Join(models.ErrInvalidInput, err)
+	}
+
+	return nil
+}
+
+func (h *ReviewHandler) writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.log.Error("failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+func setETag(w http.ResponseWriter, review models.Review) {
+	w.Header().Set("ETag", models.ETag(review.Version))
+}
+
+func (h *ReviewHandler) writeError(w http.ResponseWriter, err error) {
+	code, body := dto.FromError(err)
+	h.writeJSON(w, code, body)
+}
+
+func (h *ReviewHandler) logError(ctx context.Context, op string, err error) {
+	logger.FromContext(ctx, h.log).Error("review operation failed", slog.String("operation", op), slog.String("error", err.Error()))
+}
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"ap2final_review_service/internal/adapter/http/dto"
+	"net/http"
+)
+
+// route describes an endpoint. The same table registers the handlers and
+// generates the OpenAPI document, so the two cannot drift apart.
+type route struct {
+	method      string
+	pattern     string
+	handler     http.HandlerFunc
+	operation   string // grpc method the route serves, which names its rate limit
+	summary     string
+	description string
+	query       []param
+	header      []param
+	request     any
+	status      int
+	response    any
+	errorStatus []int
+}
+
+// param is a query or header parameter.
+type param struct {
+	name        string
+	kind        string // OpenAPI type of the value
+	format      string
+	description string
+}
+
+var ifMatchParam = param{
+	name:        "If-Match",
+	kind:        "string",
+	description: "The ETag of the review as last read. The request fails with 412 if the review has been modified since.",
+}
+
+func (s *Server) routes(h *ReviewHandler) []route {
+	return []route{
+		{
+			method:      http.MethodPost,
+			pattern:     "/reviews",
+			handler:     h.Create,
+			operation:   "Create",
+			summary:     "Create a review",
+			request:     dto.CreateReviewRequest{},
+			status:      http.StatusCreated,
+			response:    dto.ReviewResponse{},
+			errorStatus: []int{http.StatusBadRequest, http.StatusConflict},
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/reviews",
+			handler:   h.GetAll,
+			operation: "GetAll",
+			summary:   "List all reviews, or the reviews with the given ids",
+			query: []param{
+				{name: "ids", kind: "string", description: "Comma-separated review ids, at most 100."},
+			},
+			status:      http.StatusOK,
+			response:    dto.ReviewsResponse{},
+			errorStatus: []int{http.StatusBadRequest},
+		},
+		{
+			method:      http.MethodGet,
+			pattern:     "/reviews/{id}",
+			handler:     h.Get,
+			operation:   "Get",
+			summary:     "Get a review",
+			status:      http.StatusOK,
+			response:    dto.ReviewResponse{},
+			errorStatus: []int{http.StatusNotFound},
+		},
+		{
+			method:    http.MethodPatch,
+			pattern:   "/reviews/{id}",
+			handler:   h.Update,
+			operation: "Update",
+			summary:   "Update the given fields of a review",
+			query: []param{
+				{name: "update_mask", kind: "string", description: "Comma-separated fields to write, such as title or aspect_ratings.story. Listed fields left out of the body are cleared."},
+			},
+			header:      []param{ifMatchParam},
+			request:     dto.UpdateReviewRequest{},
+			status:      http.StatusOK,
+			response:    dto.ReviewResponse{},
+			errorStatus: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed},
+		},
+		{
+			method:      http.MethodDelete,
+			pattern:     "/reviews/{id}",
+			handler:     h.Delete,
+			operation:   "Delete",
+			summary:     "Soft-delete a review",
+			header:      []param{ifMatchParam},
+			status:      http.StatusOK,
+			response:    dto.ReviewResponse{},
+			errorStatus: []int{http.StatusNotFound, http.StatusPreconditionFailed},
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/movies/top",
+			handler:   h.GetTopRatedMovies,
+			operation: "GetTopRatedMovies",
+			summary:   "List the movies with the highest weighted score",
+			query: []param{
+				{name: "limit", kind: "integer", description: "Between 1 and 100, defaults to 10."},
+			},
+			status:      http.StatusOK,
+			response:    dto.TopRatedMoviesResponse{},
+			errorStatus: []int{http.StatusBadRequest},
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/movies/leaderboard",
+			handler:   h.GetMovieLeaderboard,
+			operation: "GetMovieLeaderboard",
+			summary:   "Rank movies by their reviews",
+			query: []param{
+				{name: "sort_by", kind: "string", description: "One of average, weighted or volume."},
+				{name: "window", kind: "string", description: "Only count reviews created within this duration, such as 720h."},
+				{name: "min_reviews", kind: "integer", description: "Skip movies with fewer reviews."},
+				{name: "limit", kind: "integer", description: "Between 1 and 100, defaults to 10."},
+				{name: "offset", kind: "integer"},
+			},
+			status:      http.StatusOK,
+			response:    dto.LeaderboardResponse{},
+			errorStatus: []int{http.StatusBadRequest},
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/movies/{id}/reviews",
+			handler:   h.GetByMovie,
+			operation: "GetByMovie",
+			summary:   "List the reviews of a movie",
+			status:    http.StatusOK,
+			response:  dto.ReviewsResponse{},
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/movies/{id}/rating",
+			handler:   h.GetRatingSummary,
+			operation: "GetRatingSummary",
+			summary:   "Get the rating summary of a movie",
+			status:    http.StatusOK,
+			response:  dto.RatingSummaryResponse{},
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/movies/{id}/timeline",
+			handler:   h.GetMovieRatingTimeline,
+			operation: "GetMovieRatingTimeline",
+			summary:   "Get the ratings of a movie over time",
+			query: []param{
+				{name: "bucket_size", kind: "string", description: "One of day, week or month."},
+				{name: "from", kind: "string", format: "date-time", description: "Defaults to 30 days before to."},
+				{name: "to", kind: "string", format: "date-time", description: "Defaults to now."},
+			},
+			status:      http.StatusOK,
+			response:    dto.TimelineResponse{},
+			errorStatus: []int{http.StatusBadRequest},
+		},
+		{
+			method:    http.MethodGet,
+			pattern:   "/users/{id}/reviews",
+			handler:   h.GetByUser,
+			operation: "GetByUser",
+			summary:   "List the reviews of a user",
+			status:    http.StatusOK,
+			response:  dto.ReviewsResponse{},
+		},
+		{
+			method:      http.MethodGet,
+			pattern:     "/users/{id}/stats",
+			handler:     h.GetUserReviewStats,
+			operation:   "GetUserReviewStats",
+			summary:     "Get the review statistics of a user",
+			description: "Genre breakdowns such as the most reviewed genre are not included, since movie metadata is not stored by this service.",
+			status:      http.StatusOK,
+			response:    dto.UserReviewStatsResponse{},
+		},
+	}
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"ap2final_review_service/internal/config"
+	"ap2final_review_service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sorawaslocked/ap2final_base/pkg/security"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+type Server struct {
+	s             *http.Server
+	cfg           config.HTTP
+	addr          string
+	log           *slog.Logger
+	reviewUseCase ReviewUseCase
+	ratingScale   models.RatingScale
+	rateLimiter   RateLimiter
+	jwtProvider   *security.JWTProvider
+}
+
+// New creates the JSON gateway. It serves the same review use case as the
+// grpc server; streaming and admin-only methods are grpc only. rateLimiter
+// may be nil, which disables rate limiting, and jwtProvider may be nil, in
+// which case callers are limited by peer ip.
+func New(
+	cfg config.HTTP,
+	log *slog.Logger,
+	reviewUseCase ReviewUseCase,
+	ratingScale models.RatingScale,
+	rateLimiter RateLimiter,
+	jwtProvider *security.JWTProvider,
+) *Server {
+	server := &Server{
+		cfg:           cfg,
+		addr:          fmt.Sprintf(":%d", cfg.Port),
+		log:           log,
+		reviewUseCase: reviewUseCase,
+		ratingScale:   ratingScale,
+		rateLimiter:   rateLimiter,
+		jwtProvider:   jwtProvider,
+	}
+
+	server.register()
+
+	return server
+}
+
+func (s *Server) MustRun() {
+	go func() {
+		if err := s.run(); err != nil {
+			panic(err)
+		}
+	}()
+}
+
+func (s *Server) Stop() {
+	s.log.Info("stopping http server", slog.String("addr", s.addr))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := s.s.Shutdown(ctx); err != nil {
+		s.log.Error("error stopping http server", slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) register() {
+	h := NewReviewHandler(s.reviewUseCase, s.ratingScale, s.log)
+	routes := s.routes(h)
+
+	mux := http.NewServeMux()
+	for _, r := range routes {
+		handler := rateLimit(s.rateLimiter, s.jwtProvider, s.log, r.operation, r.handler)
+		mux.Handle(r.method+" "+r.pattern, routeSpan(r.method, r.pattern, handler))
+	}
+
+	doc, err := openAPIDocument(routes)
+	if err != nil {
+		panic(err)
+	}
+
+	mux.Handle("GET /openapi.json", routeSpan(http.MethodGet, "/openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(doc)
+	}))
+
+	// The server span continues the trace of the traceparent header. It is
+	// named after the method until the mux has matched a route.
+	handler := otelhttp.NewHandler(
+		requestLogger(s.log, recoverPanic(s.log, mux)),
+		"http",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}),
+	)
+
+	s.s = &http.Server{
+		Addr:              s.addr,
+		Handler:           handler,
+		ReadHeaderTimeout: s.cfg.Timeout,
+		ReadTimeout:       s.cfg.Timeout,
+		WriteTimeout:      s.cfg.Timeout,
+	}
+}
+
+func (s *Server) run() error {
+	const op = "http.run"
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("starting http server", slog.String("addr", s.addr))
+
+	if err := s.s.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// routeSpan names the server span after the matched route, e.g.
+// GET /reviews/{id}, and records the route on it.
+func routeSpan(method, pattern string, next http.HandlerFunc) http.Handler {
+	return otelhttp.WithRouteTag(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetName(method + " " + pattern)
+		next(w, r)
+	}))
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"ap2final_review_service/internal/models"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"sync"
+	"time"
+)
+
+const namespace = "review_service"
+
+// Metrics holds the collectors of the service in its own registry.
+type Metrics struct {
+	registry       *prometheus.Registry
+	rpcRequests    *prometheus.CounterVec
+	rpcDuration    *prometheus.HistogramVec
+	dbDuration     *prometheus.HistogramVec
+	reviewsCreated prometheus.Counter
+	createdWindow  *window
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		rpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "requests_total",
+			Help:      "Handled grpc requests by method and status code.",
+		}, []string{"method", "code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of handled grpc requests by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "mongo",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of review repository operations by operation and result.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "result"}),
+		reviewsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reviews_created_total",
+			Help:      "Reviews created, including imported ones.",
+		}),
+		createdWindow: &window{},
+	}
+
+	createdPerMinute := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "reviews_created_per_minute",
+		Help:      "Reviews created within the last minute.",
+	}, func() float64 {
+		return float64(m.createdWindow.sum(time.Now()))
+	})
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.rpcRequests,
+		m.rpcDuration,
+		m.dbDuration,
+		m.reviewsCreated,
+		createdPerMinute,
+	)
+
+	return m
+}
+
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+func (m *Metrics) ObserveRPC(method, code string, duration time.Duration) {
+	m.rpcRequests.WithLabelValues(method, code).Inc()
+	m.rpcDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveOperation(operation string, err error, duration time.Duration) {
+	result := "ok"
+
+	switch {
+	case errors.Is(err, models.ErrReviewNotFound):
+		result = "not_found"
+	case errors.Is(err, models.ErrVersionConflict):
+		result = "conflict"
+	case err != nil:
+		result = "error"
+	}
+
+	m.dbDuration.WithLabelValues(operation, result).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ReviewsCreated(n int) {
+	m.reviewsCreated.Add(float64(n))
+	m.createdWindow.add(time.Now(), n)
+}
+
+// window counts events over the last minute in one-second slots.
+type window struct {
+	mu     sync.Mutex
+	counts [60]int
+	stamps [60]int64
+}
+
+func (w *window) add(now time.Time, n int) {
+	sec := now.Unix()
+	i := sec % int64(len(w.counts))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stamps[i] != sec {
+		w.stamps[i] = sec
+		w.counts[i] = 0
+	}
+	w.counts[i] += n
+}
+
+func (w *window) sum(now time.Time) int {
+	sec := now.Unix()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	total := 0
+	for i, stamp := range w.stamps {
+		if sec-stamp < int64(len(w.counts)) {
+			total += w.counts[i]
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Server serves /metrics on the admin port, apart from the public apis.
+type Server struct {
+	s    *http.Server
+	addr string
+	log  *slog.Logger
+}
+
+func NewServer(port int16, log *slog.Logger, m *Metrics) *Server {
+	addr := fmt.Sprintf(":%d", port)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(m.Registry(), promhttp.HandlerOpts{}))
+
+	return &Server{
+		s: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: shutdownTimeout,
+		},
+		addr: addr,
+		log:  log,
+	}
+}
+
+func (s *Server) MustRun() {
+	go func() {
+		if err := s.run(); err != nil {
+			panic(err)
+		}
+	}()
+}
+
+func (s *Server) Stop() {
+	s.log.Info("stopping metrics server", slog.String("addr", s.addr))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := s.s.Shutdown(ctx); err != nil {
+		s.log.Error("error stopping metrics server", slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) run() error {
+	const op = "metrics.run"
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("starting metrics server", slog.String("addr", s.addr))
+
+	if err := s.s.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// HealthCheck pings the primary, which every write of the service needs.
+type HealthCheck struct {
+	db *mongo.Database
+}
+
+func NewHealthCheck(db *mongo.Database) *HealthCheck {
+	return &HealthCheck{
+		db: db,
+	}
+}
+
+func (h *HealthCheck) Ping(ctx context.Context) error {
+	return h.db.Client().Ping(ctx, readpref.Primary())
+}
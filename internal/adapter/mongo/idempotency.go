@@ -0,0 +1,140 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ap2final_review_service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	idempotencyKeysCollection = "idempotency_keys"
+)
+
+type idempotencyRepository struct {
+	db *mongo.Database
+}
+
+func NewIdempotency(db *mongo.Database) IdempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+// EnsureIndexes creates the TTL index that removes records once they
+// expire, and the index erasure finds the records of a user by.
+func (r *idempotencyRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.db.Collection(idempotencyKeysCollection)
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys: bson.D{{Key: "user_ids", Value: 1}},
+		},
+	})
+
+	return err
+}
+
+// Reserve inserts the record. If its key is taken, the existing record is
+// replaced when it has expired, which the TTL monitor only notices later, or
+// when it was left in progress before staleBefore. Otherwise the existing
+// record is returned and reserved is false.
+func (r *idempotencyRepository) Reserve(
+	ctx context.Context,
+	record *models.IdempotencyRecord,
+	staleBefore time.Time,
+) (models.IdempotencyRecord, bool, error) {
+	collection := r.db.Collection(idempotencyKeysCollection)
+
+	_, err := collection.InsertOne(ctx, record)
+	if err == nil {
+		return *record, true, nil
+	}
+	if !IsDuplicateError(err) {
+		return models.IdempotencyRecord{}, false, err
+	}
+
+	filter := bson.M{
+		"_id": record.Key,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lte": record.CreatedAt}},
+			bson.M{
+				"status":     models.IdempotencyInProgress,
+				"created_at": bson.M{"$lte": staleBefore},
+			},
+		},
+	}
+
+	result, err := collection.ReplaceOne(ctx, filter, record)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	if result.MatchedCount == 1 {
+		return *record, true, nil
+	}
+
+	var existing models.IdempotencyRecord
+
+	err = collection.FindOne(ctx, bson.M{"_id": record.Key}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Released between the insert and the lookup.
+		return models.IdempotencyRecord{}, false, models.ErrIdempotencyInProgress
+	}
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+
+	return existing, false, nil
+}
+
+// Complete stores the response of a record, which holds the data of the
+// user with userID, if any.
+func (r *idempotencyRepository) Complete(ctx context.Context, key string, response []byte, userID string) error {
+	collection := r.db.Collection(idempotencyKeysCollection)
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":   models.IdempotencyCompleted,
+			"response": response,
+		},
+	}
+
+	if userID != "" {
+		update["$addToSet"] = bson.M{"user_ids": userID}
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": key}, update)
+
+	return err
+}
+
+// Release removes a record that is still in progress, so that the request
+// can be retried with the same key.
+func (r *idempotencyRepository) Release(ctx context.Context, key string) error {
+	collection := r.db.Collection(idempotencyKeysCollection)
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": key, "status": models.IdempotencyInProgress})
+
+	return err
+}
+
+// DeleteByUserID removes the records of requests by or about the user and
+// returns how many were removed.
+func (r *idempotencyRepository) DeleteByUserID(ctx context.Context, userID string) (int, error) {
+	collection := r.db.Collection(idempotencyKeysCollection)
+
+	result, err := collection.DeleteMany(ctx, bson.M{"user_ids": userID})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.DeletedCount), nil
+}
@@ -0,0 +1,208 @@
+package mongo
+
+import (
+	"ap2final_review_service/internal/models"
+	"ap2final_review_service/pkg/logger"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "ap2final_review_service/internal/adapter/mongo"
+
+// OperationObserver records the outcome of repository operations.
+type OperationObserver interface {
+	ObserveOperation(operation string, err error, duration time.Duration)
+	ReviewsCreated(n int)
+}
+
+type instrumentedReviewRepository struct {
+	next     ReviewRepository
+	observer OperationObserver
+	tracer   trace.Tracer
+	log      *slog.Logger
+}
+
+// NewInstrumentedReview wraps next, tracing, timing and logging every call
+// and counting the reviews it creates. Log lines go to the request logger
+// of the context when there is one. The duration of Stream includes the
+// time fn takes.
+func NewInstrumentedReview(next ReviewRepository, observer OperationObserver, log *slog.Logger) ReviewRepository {
+	return &instrumentedReviewRepository{
+		next:     next,
+		observer: observer,
+		tracer:   otel.Tracer(tracerName),
+		log:      log,
+	}
+}
+
+// start opens the span of operation. The returned function ends it and
+// records the outcome, it is meant to be deferred with the named error.
+func (r *instrumentedReviewRepository) start(ctx context.Context, operation string) (context.Context, func(*error)) {
+	start := time.Now()
+
+	ctx, span := r.tracer.Start(ctx, "reviewRepository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mongodb"),
+			attribute.String("db.collection.name", reviewsCollection),
+		),
+	)
+
+	return ctx, func(err *error) {
+		duration := time.Since(start)
+		log := logger.FromContext(ctx, r.log).With(
+			slog.String("operation", operation),
+			slog.Duration("duration", duration),
+		)
+
+		if *err != nil && !errors.Is(*err, models.ErrReviewNotFound) && !errors.Is(*err, models.ErrVersionConflict) {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+			log.Error("review repository operation failed", logger.Err(*err))
+		} else {
+			log.Debug("review repository operation finished")
+		}
+		span.End()
+
+		r.observer.ObserveOperation(operation, *err, duration)
+	}
+}
+
+func (r *instrumentedReviewRepository) Create(ctx context.Context, review *models.Review) (res models.Review, err error) {
+	ctx, finish := r.start(ctx, "create")
+	defer finish(&err)
+
+	res, err = r.next.Create(ctx, review)
+	if err == nil {
+		r.observer.ReviewsCreated(1)
+	}
+
+	return res, err
+}
+
+func (r *instrumentedReviewRepository) InsertMany(ctx context.Context, reviews []*models.Review) (errs []error, err error) {
+	ctx, finish := r.start(ctx, "insert_many")
+	defer finish(&err)
+
+	errs, err = r.next.InsertMany(ctx, reviews)
+	if err == nil {
+		created := 0
+		for _, itemErr := range errs {
+			if itemErr == nil {
+				created++
+			}
+		}
+		r.observer.ReviewsCreated(created)
+	}
+
+	return errs, err
+}
+
+func (r *instrumentedReviewRepository) FindByID(ctx context.Context, id string) (res models.Review, err error) {
+	ctx, finish := r.start(ctx, "find_by_id")
+	defer finish(&err)
+
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *instrumentedReviewRepository) Find(ctx context.Context, filter models.ReviewFilter) (res []models.Review, err error) {
+	ctx, finish := r.start(ctx, "find")
+	defer finish(&err)
+
+	return r.next.Find(ctx, filter)
+}
+
+func (r *instrumentedReviewRepository) Stream(ctx context.Context, filter models.ReviewFilter, fn func(models.Review) error) (err error) {
+	ctx, finish := r.start(ctx, "stream")
+	defer finish(&err)
+
+	return r.next.Stream(ctx, filter, fn)
+}
+
+func (r *instrumentedReviewRepository) Update(ctx context.Context, id string, update models.ReviewUpdateData) (res models.Review, err error) {
+	ctx, finish := r.start(ctx, "update")
+	defer finish(&err)
+
+	return r.next.Update(ctx, id, update)
+}
+
+func (r *instrumentedReviewRepository) Delete(ctx context.Context, id string) (res models.Review, err error) {
+	ctx, finish := r.start(ctx, "delete")
+	defer finish(&err)
+
+	return r.next.Delete(ctx, id)
+}
+
+func (r *instrumentedReviewRepository) DeleteByUserID(ctx context.Context, userID string) (n int, err error) {
+	ctx, finish := r.start(ctx, "delete_by_user_id")
+	defer finish(&err)
+
+	return r.next.DeleteByUserID(ctx, userID)
+}
+
+func (r *instrumentedReviewRepository) AnonymizeByUserID(ctx context.Context, userID string) (n int, err error) {
+	ctx, finish := r.start(ctx, "anonymize_by_user_id")
+	defer finish(&err)
+
+	return r.next.AnonymizeByUserID(ctx, userID)
+}
+
+func (r *instrumentedReviewRepository) CheckUserReviewExists(ctx context.Context, userID, movieID string) (exists bool, err error) {
+	ctx, finish := r.start(ctx, "check_user_review_exists")
+	defer finish(&err)
+
+	return r.next.CheckUserReviewExists(ctx, userID, movieID)
+}
+
+func (r *instrumentedReviewRepository) ExistingUserMovies(
+	ctx context.Context,
+	pairs []models.UserMovie,
+) (res map[models.UserMovie]bool, err error) {
+	ctx, finish := r.start(ctx, "existing_user_movies")
+	defer finish(&err)
+
+	return r.next.ExistingUserMovies(ctx, pairs)
+}
+
+func (r *instrumentedReviewRepository) GetAverageRating(ctx context.Context, movieID string) (res models.RatingSummary, err error) {
+	ctx, finish := r.start(ctx, "get_average_rating")
+	defer finish(&err)
+
+	return r.next.GetAverageRating(ctx, movieID)
+}
+
+func (r *instrumentedReviewRepository) GetMovieLeaderboard(
+	ctx context.Context,
+	query models.LeaderboardQuery,
+	since *time.Time,
+	scoring models.WeightedScoring,
+) (res models.Leaderboard, err error) {
+	ctx, finish := r.start(ctx, "get_movie_leaderboard")
+	defer finish(&err)
+
+	return r.next.GetMovieLeaderboard(ctx, query, since, scoring)
+}
+
+func (r *instrumentedReviewRepository) GetUserReviewStats(ctx context.Context, userID string) (res models.UserReviewStats, err error) {
+	ctx, finish := r.start(ctx, "get_user_review_stats")
+	defer finish(&err)
+
+	return r.next.GetUserReviewStats(ctx, userID)
+}
+
+func (r *instrumentedReviewRepository) GetMovieRatingTimeline(
+	ctx context.Context,
+	query models.TimelineQuery,
+) (res []models.RatingBucket, err error) {
+	ctx, finish := r.start(ctx, "get_movie_rating_timeline")
+	defer finish(&err)
+
+	return r.next.GetMovieRatingTimeline(ctx, query)
+}
@@ -3,14 +3,48 @@ package mongo
 import (
 	"ap2final_review_service/internal/models"
 	"context"
+	"time"
 )
 
 type ReviewRepository interface {
 	Create(ctx context.Context, review *models.Review) (models.Review, error)
+	InsertMany(ctx context.Context, reviews []*models.Review) ([]error, error)
 	FindByID(ctx context.Context, id string) (models.Review, error)
 	Find(ctx context.Context, filter models.ReviewFilter) ([]models.Review, error)
+	Stream(ctx context.Context, filter models.ReviewFilter, fn func(models.Review) error) error
 	Update(ctx context.Context, id string, update models.ReviewUpdateData) (models.Review, error)
 	Delete(ctx context.Context, id string) (models.Review, error)
+	DeleteByUserID(ctx context.Context, userID string) (int, error)
+	AnonymizeByUserID(ctx context.Context, userID string) (int, error)
 	CheckUserReviewExists(ctx context.Context, userID, movieID string) (bool, error)
-	GetAverageRating(ctx context.Context, movieID string) (float64, error)
+	ExistingUserMovies(ctx context.Context, pairs []models.UserMovie) (map[models.UserMovie]bool, error)
+	GetAverageRating(ctx context.Context, movieID string) (models.RatingSummary, error)
+	GetMovieLeaderboard(
+		ctx context.Context,
+		query models.LeaderboardQuery,
+		since *time.Time,
+		scoring models.WeightedScoring,
+	) (models.Leaderboard, error)
+	GetUserReviewStats(ctx context.Context, userID string) (models.UserReviewStats, error)
+	GetMovieRatingTimeline(ctx context.Context, query models.TimelineQuery) ([]models.RatingBucket, error)
+}
+
+type RatingStatsRepository interface {
+	Apply(ctx context.Context, delta models.MovieRatingStats) error
+	FindByMovieID(ctx context.Context, movieID string) (models.MovieRatingStats, error)
+	Rebuild(ctx context.Context) (int, error)
+	Invalidate(ctx context.Context, movieID string) error
+	Check(ctx context.Context) ([]models.MovieStatsMismatch, error)
+}
+
+type ErasureReceiptRepository interface {
+	Create(ctx context.Context, receipt *models.ErasureReceipt) (models.ErasureReceipt, error)
+}
+
+type IdempotencyRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Reserve(ctx context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (models.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, response []byte, userID string) error
+	Release(ctx context.Context, key string) error
+	DeleteByUserID(ctx context.Context, userID string) (int, error)
 }
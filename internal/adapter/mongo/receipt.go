@@ -0,0 +1,38 @@
+package mongo
+
+import (
+	"context"
+
+	"ap2final_review_service/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	erasureReceiptsCollection = "erasure_receipts"
+)
+
+type erasureReceiptRepository struct {
+	db *mongo.Database
+}
+
+func NewErasureReceipt(db *mongo.Database) ErasureReceiptRepository {
+	return &erasureReceiptRepository{
+		db: db,
+	}
+}
+
+func (r *erasureReceiptRepository) Create(ctx context.Context, receipt *models.ErasureReceipt) (models.ErasureReceipt, error) {
+	collection := r.db.Collection(erasureReceiptsCollection)
+
+	result, err := collection.InsertOne(ctx, receipt)
+	if err != nil {
+		return models.ErasureReceipt{}, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		receipt.ID = oid.Hex()
+	}
+
+	return *receipt, nil
+}
@@ -2,6 +2,9 @@ package mongo
 
 import (
 	"context"
+	"errors"
+	"math"
+	"strings"
 	"time"
 
 	"ap2final_review_service/internal/models"
@@ -13,15 +16,21 @@ import (
 
 const (
 	reviewsCollection = "reviews"
+
+	streamBatchSize = 100
 )
 
 type reviewRepository struct {
-	db *mongo.Database
+	db      *mongo.Database
+	aspects models.Aspects
 }
 
-func NewReview(db *mongo.Database) ReviewRepository {
+// NewReview creates the review repository. aspects are the rating aspects
+// averaged by GetAverageRating.
+func NewReview(db *mongo.Database, aspects models.Aspects) ReviewRepository {
 	return &reviewRepository{
-		db: db,
+		db:      db,
+		aspects: aspects,
 	}
 }
 
@@ -31,6 +40,7 @@ func (r *reviewRepository) Create(ctx context.Context, review *models.Review) (m
 	now := time.Now()
 	review.CreatedAt = now
 	review.UpdatedAt = now
+	review.Version = 1
 
 	result, err := collection.InsertOne(ctx, review)
 	if err != nil {
@@ -44,6 +54,85 @@ func (r *reviewRepository) Create(ctx context.Context, review *models.Review) (m
 	return *review, nil
 }
 
+// InsertMany inserts the reviews in one unordered batch, keeping their
+// timestamps, and sets the id of every inserted review. The returned slice
+// holds the error of each review that failed, nil for the others.
+func (r *reviewRepository) InsertMany(ctx context.Context, reviews []*models.Review) ([]error, error) {
+	collection := r.db.Collection(reviewsCollection)
+
+	errs := make([]error, len(reviews))
+	if len(reviews) == 0 {
+		return errs, nil
+	}
+
+	docs := make([]interface{}, 0, len(reviews))
+	for _, review := range reviews {
+		review.ID = primitive.NewObjectID().Hex()
+		review.Version = 1
+		docs = append(docs, reviewDocument(review))
+	}
+
+	opts := options.InsertMany().SetOrdered(false)
+
+	_, err := collection.InsertMany(ctx, docs, opts)
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			errs[writeErr.Index] = HandleMongoError(writeErr)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	for i, review := range reviews {
+		if errs[i] != nil {
+			review.ID = ""
+		}
+	}
+
+	return errs, nil
+}
+
+// ExistingUserMovies returns the pairs that already have an active review.
+func (r *reviewRepository) ExistingUserMovies(ctx context.Context, pairs []models.UserMovie) (map[models.UserMovie]bool, error) {
+	collection := r.db.Collection(reviewsCollection)
+
+	existing := make(map[models.UserMovie]bool)
+	if len(pairs) == 0 {
+		return existing, nil
+	}
+
+	or := make(bson.A, 0, len(pairs))
+	for _, pair := range pairs {
+		or = append(or, bson.M{"user_id": pair.UserID, "movie_id": pair.MovieID})
+	}
+
+	filter := bson.M{
+		"$or":        or,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	opts := options.Find().SetProjection(bson.M{"user_id": 1, "movie_id": 1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var review models.Review
+		if err := cursor.Decode(&review); err != nil {
+			return nil, err
+		}
+
+		existing[models.UserMovie{UserID: review.UserID, MovieID: review.MovieID}] = true
+	}
+
+	return existing, cursor.Err()
+}
+
 func (r *reviewRepository) FindByID(ctx context.Context, id string) (models.Review, error) {
 	collection := r.db.Collection(reviewsCollection)
 
@@ -73,6 +162,86 @@ func (r *reviewRepository) FindByID(ctx context.Context, id string) (models.Revi
 func (r *reviewRepository) Find(ctx context.Context, filter models.ReviewFilter) ([]models.Review, error) {
 	collection := r.db.Collection(reviewsCollection)
 
+	query := reviewFilterQuery(filter)
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reviews []models.Review
+	if err = cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// Stream calls fn for every review matching the filter in _id order,
+// fetching the next batch only once fn has consumed the previous one.
+// Iteration stops at the first error returned by fn or when ctx is done.
+func (r *reviewRepository) Stream(ctx context.Context, filter models.ReviewFilter, fn func(models.Review) error) error {
+	collection := r.db.Collection(reviewsCollection)
+
+	query := reviewFilterQuery(filter)
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetBatchSize(streamBatchSize)
+
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var review models.Review
+		if err := cursor.Decode(&review); err != nil {
+			return err
+		}
+
+		if err := fn(review); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// reviewDocument stores the id as an ObjectID like InsertOne does when the
+// id is left empty.
+func reviewDocument(review *models.Review) bson.M {
+	doc := bson.M{
+		"user_id":    review.UserID,
+		"movie_id":   review.MovieID,
+		"rating":     review.Rating,
+		"comment":    review.Comment,
+		"created_at": review.CreatedAt,
+		"updated_at": review.UpdatedAt,
+		"is_deleted": review.IsDeleted,
+		"version":    review.Version,
+	}
+
+	if objectID, err := primitive.ObjectIDFromHex(review.ID); err == nil {
+		doc["_id"] = objectID
+	}
+
+	if review.Title != "" {
+		doc["title"] = review.Title
+	}
+
+	if len(review.AspectRatings) > 0 {
+		doc["aspect_ratings"] = review.AspectRatings
+	}
+
+	return doc
+}
+
+func reviewFilterQuery(filter models.ReviewFilter) bson.M {
 	query := bson.M{}
 
 	if filter.ID != nil {
@@ -83,15 +252,15 @@ func (r *reviewRepository) Find(ctx context.Context, filter models.ReviewFilter)
 	}
 
 	if len(filter.IDs) > 0 {
-		var objectIDs []primitive.ObjectID
+		// IDs may be stored as ObjectIDs or plain strings, see FindByID.
+		ids := make(bson.A, 0, 2*len(filter.IDs))
 		for _, id := range filter.IDs {
+			ids = append(ids, id)
 			if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
-				objectIDs = append(objectIDs, objectID)
+				ids = append(ids, objectID)
 			}
 		}
-		if len(objectIDs) > 0 {
-			query["_id"] = bson.M{"$in": objectIDs}
-		}
+		query["_id"] = bson.M{"$in": ids}
 	}
 
 	if filter.UserID != nil {
@@ -120,55 +289,71 @@ func (r *reviewRepository) Find(ctx context.Context, filter models.ReviewFilter)
 		}
 	}
 
-	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if filter.IsDeleted != nil {
+		if *filter.IsDeleted {
+			query["is_deleted"] = true
+		} else {
+			query["is_deleted"] = bson.M{"$ne": true}
+		}
+	}
 
-	cursor, err := collection.Find(ctx, query, opts)
-	if err != nil {
-		return nil, err
+	if filter.CreatedFrom != nil || filter.CreatedTo != nil {
+		createdAt := bson.M{}
+		if filter.CreatedFrom != nil {
+			createdAt["$gte"] = *filter.CreatedFrom
+		}
+		if filter.CreatedTo != nil {
+			createdAt["$lt"] = *filter.CreatedTo
+		}
+		query["created_at"] = createdAt
 	}
-	defer cursor.Close(ctx)
 
-	var reviews []models.Review
-	if err = cursor.All(ctx, &reviews); err != nil {
-		return nil, err
+	if filter.AfterID != nil {
+		// String ids sort before ObjectIDs, so every ObjectID comes after
+		// a string id.
+		if objectID, err := primitive.ObjectIDFromHex(*filter.AfterID); err == nil {
+			query["_id"] = bson.M{"$gt": objectID}
+		} else {
+			query["$or"] = bson.A{
+				bson.M{"_id": bson.M{"$gt": *filter.AfterID}},
+				bson.M{"_id": bson.M{"$type": "objectId"}},
+			}
+		}
 	}
 
-	return reviews, nil
+	return query
 }
 
+// Update applies the update and increments the version of the review. With
+// an expected version it only applies to that version, otherwise it fails
+// with ErrVersionConflict.
 func (r *reviewRepository) Update(ctx context.Context, id string, update models.ReviewUpdateData) (models.Review, error) {
 	collection := r.db.Collection(reviewsCollection)
 
-	updateDoc := bson.M{"$set": bson.M{"updated_at": time.Now()}}
-	setDoc := updateDoc["$set"].(bson.M)
-
-	if update.Rating != nil {
-		setDoc["rating"] = *update.Rating
-	}
-
-	if update.Comment != nil {
-		setDoc["comment"] = *update.Comment
-	}
-
-	if update.IsDeleted != nil {
-		setDoc["is_deleted"] = *update.IsDeleted
-	}
+	updateDoc := reviewUpdateDocument(update)
 
 	var filter bson.M
 	var updatedReview models.Review
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
-	filter = bson.M{"_id": id}
+	filter = versionFilter(bson.M{"_id": id}, update.ExpectedVersion)
 	err := collection.FindOneAndUpdate(ctx, filter, updateDoc, opts).Decode(&updatedReview)
 
 	if err == mongo.ErrNoDocuments {
 		if objectID, convErr := primitive.ObjectIDFromHex(id); convErr == nil {
-			filter = bson.M{"_id": objectID}
+			filter = versionFilter(bson.M{"_id": objectID}, update.ExpectedVersion)
 			err = collection.FindOneAndUpdate(ctx, filter, updateDoc, opts).Decode(&updatedReview)
 		}
 	}
 
+	if err == mongo.ErrNoDocuments && update.ExpectedVersion != nil {
+		// Either the review does not exist or its version has moved on.
+		if _, findErr := r.FindByID(ctx, id); findErr == nil {
+			return models.Review{}, models.ErrVersionConflict
+		}
+	}
+
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return models.Review{}, models.ErrReviewNotFound
@@ -179,12 +364,136 @@ func (r *reviewRepository) Update(ctx context.Context, id string, update models.
 	return updatedReview, nil
 }
 
+// reviewUpdateDocument sets the fields the update writes. With a mask,
+// masked fields without a value are unset; rating and comment have been
+// checked to have one.
+func reviewUpdateDocument(update models.ReviewUpdateData) bson.M {
+	setDoc := bson.M{"updated_at": time.Now()}
+	unsetDoc := bson.M{}
+
+	if update.Mask == nil {
+		if update.Rating != nil {
+			setDoc["rating"] = *update.Rating
+		}
+
+		if update.Title != nil {
+			setDoc["title"] = *update.Title
+		}
+
+		for aspect, rating := range update.AspectRatings {
+			setDoc["aspect_ratings."+aspect] = rating
+		}
+
+		if update.Comment != nil {
+			setDoc["comment"] = *update.Comment
+		}
+
+		if update.IsDeleted != nil {
+			setDoc["is_deleted"] = *update.IsDeleted
+		}
+	}
+
+	for _, path := range update.Mask {
+		switch path {
+		case models.UpdatePathRating:
+			setDoc["rating"] = *update.Rating
+		case models.UpdatePathTitle:
+			if update.Title != nil && *update.Title != "" {
+				setDoc["title"] = *update.Title
+			} else {
+				unsetDoc["title"] = ""
+			}
+		case models.UpdatePathAspectRatings:
+			if len(update.AspectRatings) > 0 {
+				setDoc["aspect_ratings"] = update.AspectRatings
+			} else {
+				unsetDoc["aspect_ratings"] = ""
+			}
+		case models.UpdatePathComment:
+			setDoc["comment"] = *update.Comment
+		case models.UpdatePathIsDeleted:
+			setDoc["is_deleted"] = update.IsDeleted != nil && *update.IsDeleted
+		default:
+			// A single aspect, aspect_ratings.<aspect>.
+			aspect := strings.TrimPrefix(path, models.UpdatePathAspectRatings+".")
+			if rating, ok := update.AspectRatings[aspect]; ok {
+				setDoc[path] = rating
+			} else {
+				unsetDoc[path] = ""
+			}
+		}
+	}
+
+	updateDoc := bson.M{
+		"$set": setDoc,
+		"$inc": bson.M{"version": 1},
+	}
+	if len(unsetDoc) > 0 {
+		updateDoc["$unset"] = unsetDoc
+	}
+
+	return updateDoc
+}
+
+// versionFilter restricts filter to the expected version, if there is one.
+// Documents written before versions were introduced count as version 0.
+func versionFilter(filter bson.M, expectedVersion *int64) bson.M {
+	if expectedVersion == nil {
+		return filter
+	}
+
+	if *expectedVersion == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = *expectedVersion
+	}
+
+	return filter
+}
+
 func (r *reviewRepository) Delete(ctx context.Context, id string) (models.Review, error) {
 	return r.Update(ctx, id, models.ReviewUpdateData{
 		IsDeleted: models.BoolPtr(true),
 	})
 }
 
+// DeleteByUserID removes every review of the user, including soft-deleted
+// ones, and returns how many were removed.
+func (r *reviewRepository) DeleteByUserID(ctx context.Context, userID string) (int, error) {
+	collection := r.db.Collection(reviewsCollection)
+
+	result, err := collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.DeletedCount), nil
+}
+
+// AnonymizeByUserID replaces the user id of every review of the user with a
+// tombstone derived from the review id and removes the title and comment.
+// Ratings are kept.
+func (r *reviewRepository) AnonymizeByUserID(ctx context.Context, userID string) (int, error) {
+	collection := r.db.Collection(reviewsCollection)
+
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"user_id":    bson.M{"$concat": bson.A{models.ErasedUserIDPrefix, bson.M{"$toString": "$_id"}}},
+			"comment":    "",
+			"updated_at": time.Now(),
+			"version":    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+		}},
+		bson.M{"$unset": "title"},
+	}
+
+	result, err := collection.UpdateMany(ctx, bson.M{"user_id": userID}, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.ModifiedCount), nil
+}
+
 func (r *reviewRepository) CheckUserReviewExists(ctx context.Context, userID, movieID string) (bool, error) {
 	collection := r.db.Collection(reviewsCollection)
 
@@ -202,9 +511,19 @@ func (r *reviewRepository) CheckUserReviewExists(ctx context.Context, userID, mo
 	return count > 0, nil
 }
 
-func (r *reviewRepository) GetAverageRating(ctx context.Context, movieID string) (float64, error) {
+func (r *reviewRepository) GetAverageRating(ctx context.Context, movieID string) (models.RatingSummary, error) {
 	collection := r.db.Collection(reviewsCollection)
 
+	group := bson.M{
+		"_id":            nil,
+		"average_rating": bson.M{"$avg": "$rating"},
+		"review_count":   bson.M{"$sum": 1},
+	}
+
+	for _, aspect := range r.aspects {
+		group[aspectAverageField(aspect)] = bson.M{"$avg": "$aspect_ratings." + aspect}
+	}
+
 	pipeline := []bson.M{
 		{
 			"$match": bson.M{
@@ -212,37 +531,355 @@ func (r *reviewRepository) GetAverageRating(ctx context.Context, movieID string)
 				"is_deleted": bson.M{"$ne": true},
 			},
 		},
+		{
+			"$group": group,
+		},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.RatingSummary{}, err
+	}
+	defer cursor.Close(ctx)
+
+	summary := models.RatingSummary{
+		MovieID:        movieID,
+		AspectAverages: map[string]float64{},
+	}
+
+	if cursor.Next(ctx) {
+		var result bson.M
+		if err := cursor.Decode(&result); err != nil {
+			return models.RatingSummary{}, err
+		}
+
+		summary.AverageRating = toFloat64(result["average_rating"])
+		summary.ReviewCount = int(toFloat64(result["review_count"]))
+
+		for _, aspect := range r.aspects {
+			// $avg yields null when no review rated the aspect.
+			if value, ok := result[aspectAverageField(aspect)]; ok && value != nil {
+				summary.AspectAverages[aspect] = toFloat64(value)
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+func (r *reviewRepository) GetMovieLeaderboard(
+	ctx context.Context,
+	query models.LeaderboardQuery,
+	since *time.Time,
+	scoring models.WeightedScoring,
+) (models.Leaderboard, error) {
+	collection := r.db.Collection(reviewsCollection)
+
+	match := bson.M{
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	if since != nil {
+		match["created_at"] = bson.M{"$gte": *since}
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": match,
+		},
 		{
 			"$group": bson.M{
-				"_id":            nil,
+				"_id":            "$movie_id",
 				"average_rating": bson.M{"$avg": "$rating"},
+				"rating_sum":     bson.M{"$sum": "$rating"},
 				"review_count":   bson.M{"$sum": 1},
 			},
 		},
+		{
+			"$match": bson.M{
+				"review_count": bson.M{"$gte": query.MinReviews},
+			},
+		},
+		{
+			"$addFields": bson.M{
+				"weighted_score": weightedScoreExpr(scoring),
+			},
+		},
+		{
+			"$facet": bson.M{
+				"total": bson.A{
+					bson.M{"$count": "count"},
+				},
+				"entries": bson.A{
+					bson.M{"$sort": leaderboardSort(query.SortBy)},
+					bson.M{"$skip": query.Offset},
+					bson.M{"$limit": query.Limit},
+				},
+			},
+		},
 	}
 
 	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return 0, err
+		return models.Leaderboard{}, err
 	}
 	defer cursor.Close(ctx)
 
 	var result struct {
-		AverageRating float64 `bson:"average_rating"`
-		ReviewCount   int     `bson:"review_count"`
+		Total []struct {
+			Count int `bson:"count"`
+		} `bson:"total"`
+		Entries []movieRatingResult `bson:"entries"`
 	}
 
 	if cursor.Next(ctx) {
 		if err := cursor.Decode(&result); err != nil {
-			return 0, err
+			return models.Leaderboard{}, err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return models.Leaderboard{}, err
+	}
+
+	leaderboard := models.Leaderboard{
+		Entries: make([]models.LeaderboardEntry, 0, len(result.Entries)),
+	}
+
+	if len(result.Total) > 0 {
+		leaderboard.Total = result.Total[0].Count
+	}
+
+	for i, entry := range result.Entries {
+		leaderboard.Entries = append(leaderboard.Entries, models.LeaderboardEntry{
+			Rank:          query.Offset + i + 1,
+			RatingSummary: entry.toSummary(),
+		})
+	}
+
+	return leaderboard, nil
+}
+
+func leaderboardSort(sortBy models.LeaderboardSort) bson.D {
+	switch sortBy {
+	case models.LeaderboardSortAverage:
+		return bson.D{
+			{Key: "average_rating", Value: -1},
+			{Key: "review_count", Value: -1},
+			{Key: "_id", Value: 1},
+		}
+	case models.LeaderboardSortVolume:
+		return bson.D{
+			{Key: "review_count", Value: -1},
+			{Key: "average_rating", Value: -1},
+			{Key: "_id", Value: 1},
+		}
+	default:
+		return bson.D{
+			{Key: "weighted_score", Value: -1},
+			{Key: "review_count", Value: -1},
+			{Key: "_id", Value: 1},
 		}
+	}
+}
+
+func (r *reviewRepository) GetUserReviewStats(ctx context.Context, userID string) (models.UserReviewStats, error) {
+	collection := r.db.Collection(reviewsCollection)
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"user_id":    userID,
+				"is_deleted": bson.M{"$ne": true},
+			},
+		},
+		{
+			"$facet": bson.M{
+				"summary": bson.A{
+					bson.M{"$group": bson.M{
+						"_id":             nil,
+						"review_count":    bson.M{"$sum": 1},
+						"average_rating":  bson.M{"$avg": "$rating"},
+						"first_review_at": bson.M{"$min": "$created_at"},
+						"last_review_at":  bson.M{"$max": "$created_at"},
+					}},
+				},
+				"distribution": bson.A{
+					bson.M{"$group": bson.M{
+						"_id":          "$rating",
+						"review_count": bson.M{"$sum": 1},
+					}},
+				},
+				"monthly": bson.A{
+					bson.M{"$group": bson.M{
+						"_id":            bson.M{"$dateTrunc": bson.M{"date": "$created_at", "unit": "month"}},
+						"review_count":   bson.M{"$sum": 1},
+						"average_rating": bson.M{"$avg": "$rating"},
+					}},
+					bson.M{"$sort": bson.M{"_id": 1}},
+				},
+			},
+		},
+	}
 
-		if result.ReviewCount == 0 {
-			return 0, nil
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.UserReviewStats{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Summary []struct {
+			ReviewCount   int       `bson:"review_count"`
+			AverageRating float64   `bson:"average_rating"`
+			FirstReviewAt time.Time `bson:"first_review_at"`
+			LastReviewAt  time.Time `bson:"last_review_at"`
+		} `bson:"summary"`
+		Distribution []struct {
+			Rating      float64 `bson:"_id"`
+			ReviewCount int     `bson:"review_count"`
+		} `bson:"distribution"`
+		Monthly []ratingBucketResult `bson:"monthly"`
+	}
+
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return models.UserReviewStats{}, err
 		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return models.UserReviewStats{}, err
+	}
+
+	stats := models.UserReviewStats{
+		UserID:       userID,
+		Distribution: map[float64]int{},
+		Monthly:      toRatingBuckets(result.Monthly),
+	}
+
+	if len(result.Summary) > 0 {
+		summary := result.Summary[0]
+		stats.ReviewCount = summary.ReviewCount
+		stats.AverageRating = summary.AverageRating
+		stats.FirstReviewAt = summary.FirstReviewAt
+		stats.LastReviewAt = summary.LastReviewAt
+	}
+
+	for _, bucket := range result.Distribution {
+		stars := math.Round(bucket.Rating*2) / 2
+		stats.Distribution[stars] += bucket.ReviewCount
+	}
+
+	return stats, nil
+}
+
+func (r *reviewRepository) GetMovieRatingTimeline(ctx context.Context, query models.TimelineQuery) ([]models.RatingBucket, error) {
+	collection := r.db.Collection(reviewsCollection)
+
+	dateTrunc := bson.M{
+		"date": "$created_at",
+		"unit": string(query.BucketSize),
+	}
+
+	if query.BucketSize == models.BucketWeek {
+		dateTrunc["startOfWeek"] = "monday"
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"movie_id":   query.MovieID,
+				"is_deleted": bson.M{"$ne": true},
+				"created_at": bson.M{"$gte": query.From, "$lt": query.To},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":            bson.M{"$dateTrunc": dateTrunc},
+				"review_count":   bson.M{"$sum": 1},
+				"average_rating": bson.M{"$avg": "$rating"},
+			},
+		},
+		{
+			"$sort": bson.M{"_id": 1},
+		},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []ratingBucketResult
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return toRatingBuckets(results), nil
+}
+
+type ratingBucketResult struct {
+	Start         time.Time `bson:"_id"`
+	ReviewCount   int       `bson:"review_count"`
+	AverageRating float64   `bson:"average_rating"`
+}
 
-		return result.AverageRating, nil
+func toRatingBuckets(results []ratingBucketResult) []models.RatingBucket {
+	buckets := make([]models.RatingBucket, 0, len(results))
+	for _, result := range results {
+		buckets = append(buckets, models.RatingBucket{
+			Start:         result.Start,
+			ReviewCount:   result.ReviewCount,
+			AverageRating: result.AverageRating,
+		})
 	}
 
-	return 0, nil
+	return buckets
+}
+
+type movieRatingResult struct {
+	MovieID       string  `bson:"_id"`
+	AverageRating float64 `bson:"average_rating"`
+	ReviewCount   int     `bson:"review_count"`
+	WeightedScore float64 `bson:"weighted_score"`
+}
+
+func (r movieRatingResult) toSummary() models.RatingSummary {
+	return models.RatingSummary{
+		MovieID:       r.MovieID,
+		AverageRating: r.AverageRating,
+		ReviewCount:   r.ReviewCount,
+		WeightedScore: r.WeightedScore,
+	}
+}
+
+// weightedScoreExpr mirrors models.WeightedScoring.Score, expecting
+// rating_sum and review_count on the grouped document.
+func weightedScoreExpr(scoring models.WeightedScoring) bson.M {
+	return bson.M{
+		"$divide": bson.A{
+			bson.M{"$add": bson.A{"$rating_sum", scoring.PriorMean * float64(scoring.MinVotes)}},
+			bson.M{"$add": bson.A{"$review_count", scoring.MinVotes}},
+		},
+	}
+}
+
+func aspectAverageField(aspect string) string {
+	return "aspect_" + aspect + "_average"
+}
+
+func toFloat64(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	}
+	return 0
 }
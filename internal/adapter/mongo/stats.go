@@ -0,0 +1,357 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ap2final_review_service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	movieRatingStatsCollection = "movie_rating_stats"
+	maxRebuildAttempts         = 5
+)
+
+var errStatsChanged = errors.New("movie rating stats changed during rebuild")
+
+type ratingStatsRepository struct {
+	db *mongo.Database
+}
+
+func NewRatingStats(db *mongo.Database) RatingStatsRepository {
+	return &ratingStatsRepository{
+		db: db,
+	}
+}
+
+// Apply increments the stats of the delta's movie. Stats start from zero
+// on the first write of a movie, so concurrent writes never need a seed
+// and each delta is counted once. Stats of movies reviewed before stats
+// were introduced are written by the review-stats rebuild.
+func (r *ratingStatsRepository) Apply(ctx context.Context, delta models.MovieRatingStats) error {
+	if delta.IsZero() {
+		return nil
+	}
+
+	collection := r.db.Collection(movieRatingStatsCollection)
+
+	inc := bson.M{
+		"count":      delta.Count,
+		"sum":        delta.Sum,
+		"generation": 1,
+	}
+
+	for key, n := range delta.Histogram {
+		if n != 0 {
+			inc["histogram."+key] = n
+		}
+	}
+
+	for aspect, n := range delta.AspectCounts {
+		if n != 0 {
+			inc["aspect_counts."+aspect] = n
+			inc["aspect_sums."+aspect] = delta.AspectSums[aspect]
+		}
+	}
+
+	update := bson.M{
+		"$inc": inc,
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": delta.MovieID}, update, opts)
+
+	return err
+}
+
+func (r *ratingStatsRepository) FindByMovieID(ctx context.Context, movieID string) (models.MovieRatingStats, error) {
+	collection := r.db.Collection(movieRatingStatsCollection)
+
+	var stats models.MovieRatingStats
+
+	filter := bson.M{"_id": movieID, "stale": bson.M{"$ne": true}}
+
+	err := collection.FindOne(ctx, filter).Decode(&stats)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.MovieRatingStats{}, models.ErrMovieStatsNotFound
+		}
+		return models.MovieRatingStats{}, err
+	}
+
+	return stats, nil
+}
+
+// Rebuild recomputes the stats of every movie from the reviews collection
+// and returns the number of movies written. Stats of movies without active
+// reviews are removed. A movie whose stats are incremented while they are
+// recomputed is rebuilt again, so the increment is not overwritten.
+func (r *ratingStatsRepository) Rebuild(ctx context.Context) (int, error) {
+	generations, err := r.generations(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+
+	computed, err := r.compute(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+
+	for movieID := range generations {
+		if _, ok := computed[movieID]; !ok {
+			computed[movieID] = models.NewMovieRatingStats(movieID)
+		}
+	}
+
+	written := 0
+
+	for movieID, stats := range computed {
+		generation, stored := generations[movieID]
+
+		err := r.store(ctx, stats, generation, stored)
+		if errors.Is(err, errStatsChanged) {
+			err = r.rebuildMovie(ctx, movieID)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if !stats.IsZero() {
+			written++
+		}
+	}
+
+	return written, nil
+}
+
+// rebuildMovie recomputes the stats of a single movie, retrying while
+// increments land during the computation.
+func (r *ratingStatsRepository) rebuildMovie(ctx context.Context, movieID string) error {
+	for attempt := 1; ; attempt++ {
+		generations, err := r.generations(ctx, bson.M{"_id": movieID})
+		if err != nil {
+			return err
+		}
+
+		computed, err := r.compute(ctx, bson.M{"movie_id": movieID})
+		if err != nil {
+			return err
+		}
+
+		stats, ok := computed[movieID]
+		if !ok {
+			stats = models.NewMovieRatingStats(movieID)
+		}
+
+		generation, stored := generations[movieID]
+
+		err = r.store(ctx, stats, generation, stored)
+		if errors.Is(err, errStatsChanged) && attempt < maxRebuildAttempts {
+			continue
+		}
+
+		return err
+	}
+}
+
+// store replaces the stats of a movie with recomputed ones, if the stored
+// stats are still at generation, and removes them if the movie has no
+// active reviews.
+func (r *ratingStatsRepository) store(ctx context.Context, stats models.MovieRatingStats, generation int64, stored bool) error {
+	collection := r.db.Collection(movieRatingStatsCollection)
+
+	filter := bson.M{"_id": stats.MovieID, "generation": generation}
+	if generation == 0 {
+		filter["generation"] = bson.M{"$exists": false}
+	}
+
+	if stats.IsZero() {
+		if !stored {
+			return nil
+		}
+
+		result, err := collection.DeleteOne(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			return errStatsChanged
+		}
+
+		return nil
+	}
+
+	stats.Generation = generation + 1
+	stats.UpdatedAt = time.Now()
+
+	if !stored {
+		_, err := collection.InsertOne(ctx, stats)
+		if mongo.IsDuplicateKeyError(err) {
+			return errStatsChanged
+		}
+		return err
+	}
+
+	result, err := collection.ReplaceOne(ctx, filter, stats)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errStatsChanged
+	}
+
+	return nil
+}
+
+// generations returns the generation of the stored stats matching filter
+// by movie. Stats without one predate the field.
+func (r *ratingStatsRepository) generations(ctx context.Context, filter bson.M) (map[string]int64, error) {
+	collection := r.db.Collection(movieRatingStatsCollection)
+
+	opts := options.Find().SetProjection(bson.M{"generation": 1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	generations := make(map[string]int64)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			MovieID    string `bson:"_id"`
+			Generation int64  `bson:"generation"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+
+		generations[doc.MovieID] = doc.Generation
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return generations, nil
+}
+
+// Invalidate marks the stats of a movie as stale, e.g. after a failed Apply
+// left them out of date. Reads fall back to the reviews collection until
+// the next review-stats rebuild. The stats are kept rather than removed,
+// so that later increments do not start them from zero again.
+func (r *ratingStatsRepository) Invalidate(ctx context.Context, movieID string) error {
+	collection := r.db.Collection(movieRatingStatsCollection)
+
+	update := bson.M{"$set": bson.M{"stale": true}}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": movieID}, update, opts)
+
+	return err
+}
+
+// Check compares the stored stats with stats recomputed from the reviews
+// collection and returns every movie where they differ.
+func (r *ratingStatsRepository) Check(ctx context.Context) ([]models.MovieStatsMismatch, error) {
+	computed, err := r.compute(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	collection := r.db.Collection(movieRatingStatsCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var mismatches []models.MovieStatsMismatch
+	seen := make(map[string]bool, len(computed))
+
+	for cursor.Next(ctx) {
+		var stored models.MovieRatingStats
+		if err := cursor.Decode(&stored); err != nil {
+			return nil, err
+		}
+
+		seen[stored.MovieID] = true
+
+		expected, ok := computed[stored.MovieID]
+		if !ok {
+			expected = models.NewMovieRatingStats(stored.MovieID)
+		}
+
+		if !stored.Equal(expected) {
+			mismatches = append(mismatches, models.MovieStatsMismatch{
+				MovieID:  stored.MovieID,
+				Stored:   stored,
+				Expected: expected,
+			})
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	for movieID, expected := range computed {
+		if !seen[movieID] {
+			mismatches = append(mismatches, models.MovieStatsMismatch{
+				MovieID:  movieID,
+				Stored:   models.NewMovieRatingStats(movieID),
+				Expected: expected,
+			})
+		}
+	}
+
+	return mismatches, nil
+}
+
+func (r *ratingStatsRepository) compute(ctx context.Context, filter bson.M) (map[string]models.MovieRatingStats, error) {
+	collection := r.db.Collection(reviewsCollection)
+
+	filter["is_deleted"] = bson.M{"$ne": true}
+
+	opts := options.Find().SetProjection(bson.M{
+		"movie_id":       1,
+		"rating":         1,
+		"aspect_ratings": 1,
+	})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	computed := make(map[string]models.MovieRatingStats)
+
+	for cursor.Next(ctx) {
+		var review models.Review
+		if err := cursor.Decode(&review); err != nil {
+			return nil, err
+		}
+
+		stats, ok := computed[review.MovieID]
+		if !ok {
+			stats = models.NewMovieRatingStats(review.MovieID)
+		}
+
+		stats.Add(review)
+		computed[review.MovieID] = stats
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return computed, nil
+}
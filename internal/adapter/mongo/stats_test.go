@@ -0,0 +1,194 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"ap2final_review_service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDatabase returns an empty database on the server of MONGO_TEST_URI,
+// which is dropped after the test.
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := client.Database(fmt.Sprintf("review_service_test_%d", time.Now().UnixNano()))
+
+	t.Cleanup(func() {
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+
+	return db
+}
+
+// writeReview inserts a review and applies it to the stats, like a create.
+func writeReview(ctx context.Context, db *mongo.Database, repo RatingStatsRepository, review models.Review) error {
+	if _, err := db.Collection(reviewsCollection).InsertOne(ctx, review); err != nil {
+		return err
+	}
+
+	return repo.Apply(ctx, models.NewMovieRatingStatsDelta(nil, &review))
+}
+
+func testReview(i int) models.Review {
+	return models.Review{
+		ID:            fmt.Sprintf("review-%03d", i),
+		MovieID:       "movie",
+		Rating:        float64(i%10+1) / 2,
+		AspectRatings: map[string]float64{"story": float64(i%5 + 1)},
+	}
+}
+
+func assertStatsExact(t *testing.T, repo RatingStatsRepository, count int) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	mismatches, err := repo.Check(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range mismatches {
+		t.Errorf("movie %s: stored %+v, expected %+v", m.MovieID, m.Stored, m.Expected)
+	}
+
+	stats, err := repo.FindByMovieID(ctx, "movie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Count != count {
+		t.Errorf("got count %d, want %d", stats.Count, count)
+	}
+}
+
+func TestRatingStatsConcurrentApply(t *testing.T) {
+	db := testDatabase(t)
+	repo := NewRatingStats(db)
+	ctx := context.Background()
+
+	const writers = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- writeReview(ctx, db, repo, testReview(i))
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertStatsExact(t, repo, writers)
+}
+
+func TestRatingStatsRebuildDetectsConcurrentApply(t *testing.T) {
+	db := testDatabase(t)
+	repo := &ratingStatsRepository{db: db}
+	ctx := context.Background()
+
+	// Reviews written before stats existed.
+	for i := 0; i < 3; i++ {
+		if _, err := db.Collection(reviewsCollection).InsertOne(ctx, testReview(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	computed, err := repo.compute(ctx, bson.M{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A write lands between the computation and the store of a rebuild of
+	// a movie without stats.
+	if err := writeReview(ctx, db, repo, testReview(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.store(ctx, computed["movie"], 0, false); !errors.Is(err, errStatsChanged) {
+		t.Fatalf("store over a new increment: got %v, want %v", err, errStatsChanged)
+	}
+
+	if _, err := repo.Rebuild(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatsExact(t, repo, 4)
+
+	// And one that lands during a rebuild of stored stats.
+	generations, err := repo.generations(ctx, bson.M{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	computed, err = repo.compute(ctx, bson.M{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeReview(ctx, db, repo, testReview(4)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.store(ctx, computed["movie"], generations["movie"], true); !errors.Is(err, errStatsChanged) {
+		t.Fatalf("store over an increment: got %v, want %v", err, errStatsChanged)
+	}
+	assertStatsExact(t, repo, 5)
+
+	if _, err := repo.Rebuild(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatsExact(t, repo, 5)
+}
+
+func TestRatingStatsInvalidate(t *testing.T) {
+	db := testDatabase(t)
+	repo := NewRatingStats(db)
+	ctx := context.Background()
+
+	if err := writeReview(ctx, db, repo, testReview(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Invalidate(ctx, "movie"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.FindByMovieID(ctx, "movie"); !errors.Is(err, models.ErrMovieStatsNotFound) {
+		t.Fatalf("invalidated stats: got %v, want %v", err, models.ErrMovieStatsNotFound)
+	}
+
+	// Later writes keep counting, the rebuild clears the mark.
+	if err := writeReview(ctx, db, repo, testReview(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Rebuild(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatsExact(t, repo, 2)
+}
@@ -0,0 +1,167 @@
+package mongo
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"ap2final_review_service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const watchRetryDelay = 5 * time.Second
+
+type ReviewEventPublisher interface {
+	Publish(event models.ReviewEvent)
+}
+
+// ReviewWatcher publishes review events from a change stream on the reviews
+// collection, which also covers writes made by other service instances.
+// Change streams are only available on replica sets and sharded clusters.
+// Hard deletes carry the deleted review when the collection records
+// pre-images (MongoDB 6.0+), and only its id otherwise.
+type ReviewWatcher struct {
+	db        *mongo.Database
+	publisher ReviewEventPublisher
+	log       *slog.Logger
+}
+
+type reviewChange struct {
+	OperationType            string         `bson:"operationType"`
+	FullDocument             models.Review  `bson:"fullDocument"`
+	FullDocumentBeforeChange *models.Review `bson:"fullDocumentBeforeChange"`
+	DocumentKey              struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+	WallTime          time.Time `bson:"wallTime"`
+	UpdateDescription struct {
+		UpdatedFields bson.M `bson:"updatedFields"`
+	} `bson:"updateDescription"`
+}
+
+func NewReviewWatcher(db *mongo.Database, publisher ReviewEventPublisher, log *slog.Logger) *ReviewWatcher {
+	return &ReviewWatcher{
+		db:        db,
+		publisher: publisher,
+		log:       log,
+	}
+}
+
+// Run watches until ctx is done, reopening the change stream after errors
+// from the last seen resume token. A stream invalidated by e.g. dropping
+// the collection cannot be resumed and is reopened from the current time.
+func (w *ReviewWatcher) Run(ctx context.Context) {
+	if err := w.enablePreImages(ctx); err != nil {
+		w.log.Warn(
+			"review pre-images unavailable, delete events only carry the review id",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	var resumeToken bson.Raw
+
+	for {
+		token, err := w.watch(ctx, resumeToken)
+		resumeToken = token
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			w.log.Error("review change stream failed", slog.String("error", err.Error()))
+		} else {
+			w.log.Warn("review change stream invalidated, reopening")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryDelay):
+		}
+	}
+}
+
+// enablePreImages makes the reviews collection record the state of
+// documents before a change, so delete events can carry the deleted review.
+func (w *ReviewWatcher) enablePreImages(ctx context.Context) error {
+	command := bson.D{
+		{Key: "collMod", Value: reviewsCollection},
+		{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+	}
+
+	return w.db.RunCommand(ctx, command).Err()
+}
+
+// watch publishes changes until the stream fails or ends and returns the
+// token to resume from. The token is nil once the stream has been
+// invalidated, which is the only way it ends without an error.
+func (w *ReviewWatcher) watch(ctx context.Context, resumeToken bson.Raw) (bson.Raw, error) {
+	collection := w.db.Collection(reviewsCollection)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete", "invalidate"}},
+		}}},
+	}
+
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if resumeToken != nil {
+		opts.SetResumeAfter(resumeToken)
+	}
+
+	stream, err := collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return resumeToken, err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var change reviewChange
+		if err := stream.Decode(&change); err != nil {
+			return stream.ResumeToken(), err
+		}
+
+		if change.OperationType == "invalidate" {
+			return nil, nil
+		}
+
+		w.publisher.Publish(change.toEvent())
+	}
+
+	if err := stream.Err(); err != nil {
+		return stream.ResumeToken(), err
+	}
+
+	return nil, nil
+}
+
+func (c reviewChange) toEvent() models.ReviewEvent {
+	event := models.ReviewEvent{
+		Type:       models.ReviewUpdated,
+		Review:     c.FullDocument,
+		OccurredAt: c.WallTime,
+	}
+
+	if c.OperationType == "insert" {
+		event.Type = models.ReviewCreated
+	} else if c.OperationType == "delete" {
+		event.Type = models.ReviewDeleted
+		event.Review = models.Review{ID: c.DocumentKey.ID}
+		if c.FullDocumentBeforeChange != nil {
+			event.Review = *c.FullDocumentBeforeChange
+		}
+	} else if _, ok := c.UpdateDescription.UpdatedFields["is_deleted"]; ok && c.FullDocument.IsDeleted {
+		event.Type = models.ReviewDeleted
+	}
+
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	return event
+}
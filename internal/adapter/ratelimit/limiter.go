@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Limit allows Rate calls per second on average with bursts of up to Burst
+// calls. A non-positive Rate disables limiting.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+var ErrInvalidLimit = errors.New("rate limit burst must be at least 1")
+
+// Validate rejects limits that would refuse every call.
+func (l Limit) Validate() error {
+	if !l.unlimited() && l.Burst < 1 {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+func (l Limit) unlimited() bool {
+	return l.Rate <= 0
+}
+
+// burst is at least one call, otherwise no call could ever pass.
+func (l Limit) burst() float64 {
+	if l.Burst < 1 {
+		return 1
+	}
+	return float64(l.Burst)
+}
+
+// Store keeps the token buckets. It is an interface so that replicas can
+// share their buckets through a common backend instead of MemoryStore.
+type Store interface {
+	// Take removes a token from the bucket of key. If the bucket is empty
+	// it returns false and how long until the next token is available.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error)
+}
+
+// Limiter applies per-operation limits, falling back to a default limit.
+// The grpc and http servers name operations alike, so a caller shares its
+// buckets between both.
+type Limiter struct {
+	store   Store
+	def     Limit
+	methods map[string]Limit
+}
+
+// New creates a limiter. methods is keyed by operation name, such as Create,
+// and overrides def for that operation.
+func New(store Store, def Limit, methods map[string]Limit) *Limiter {
+	return &Limiter{
+		store:   store,
+		def:     def,
+		methods: methods,
+	}
+}
+
+// Allow reports whether the caller identified by key may call operation
+// now, and otherwise when to retry.
+func (l *Limiter) Allow(ctx context.Context, operation, key string) (bool, time.Duration, error) {
+	limit, ok := l.methods[operation]
+	if !ok {
+		limit = l.def
+	}
+
+	if limit.unlimited() {
+		return true, 0, nil
+	}
+
+	return l.store.Take(ctx, operation+"|"+key, limit, time.Now())
+}
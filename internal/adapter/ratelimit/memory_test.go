@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	type take struct {
+		after      time.Duration // since the previous take
+		allowed    bool
+		retryAfter time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		limit Limit
+		takes []take
+	}{
+		{
+			name:  "burst then refill",
+			limit: Limit{Rate: 1, Burst: 3},
+			takes: []take{
+				{allowed: true},
+				{allowed: true},
+				{allowed: true},
+				{allowed: false, retryAfter: time.Second},
+				{after: 500 * time.Millisecond, allowed: false, retryAfter: 500 * time.Millisecond},
+				{after: 500 * time.Millisecond, allowed: true},
+				{allowed: false, retryAfter: time.Second},
+			},
+		},
+		{
+			name:  "refill capped at burst",
+			limit: Limit{Rate: 1, Burst: 2},
+			takes: []take{
+				{allowed: true},
+				{after: time.Hour, allowed: true},
+				{allowed: true},
+				{allowed: false, retryAfter: time.Second},
+			},
+		},
+		{
+			name:  "slow rate",
+			limit: Limit{Rate: 0.5, Burst: 1},
+			takes: []take{
+				{allowed: true},
+				{allowed: false, retryAfter: 2 * time.Second},
+				{after: time.Second, allowed: false, retryAfter: time.Second},
+				{after: time.Second, allowed: true},
+			},
+		},
+		{
+			name:  "slow rate kept across sweeps",
+			limit: Limit{Rate: 1.0 / 120, Burst: 1},
+			takes: []take{
+				{allowed: true},
+				{after: 90 * time.Second, allowed: false, retryAfter: 30 * time.Second},
+				{after: 30 * time.Second, allowed: true},
+			},
+		},
+		{
+			name:  "zero burst allows one call",
+			limit: Limit{Rate: 1},
+			takes: []take{
+				{allowed: true},
+				{allowed: false, retryAfter: time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			now := store.lastSweep
+
+			for i, take := range tt.takes {
+				now = now.Add(take.after)
+
+				allowed, retryAfter, err := store.Take(context.Background(), "key", tt.limit, now)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if allowed != take.allowed {
+					t.Fatalf("take %d: got allowed %t, want %t", i, allowed, take.allowed)
+				}
+				if diff := retryAfter - take.retryAfter; diff < -time.Millisecond || diff > time.Millisecond {
+					t.Fatalf("take %d: got retry after %v, want %v", i, retryAfter, take.retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	now := time.Now()
+
+	for _, key := range []string{"a", "b"} {
+		allowed, _, err := store.Take(context.Background(), key, limit, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !allowed {
+			t.Fatalf("first take of %s was refused", key)
+		}
+	}
+}
+
+func TestLimitValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit Limit
+		err   error
+	}{
+		{name: "valid", limit: Limit{Rate: 10, Burst: 20}},
+		{name: "disabled without burst", limit: Limit{}},
+		{name: "zero burst", limit: Limit{Rate: 10}, err: ErrInvalidLimit},
+		{name: "negative burst", limit: Limit{Rate: 10, Burst: -1}, err: ErrInvalidLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.limit.Validate(); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
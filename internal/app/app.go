@@ -1,24 +1,44 @@
 package app
 
 import (
+	"ap2final_review_service/internal/adapter/broadcast"
 	grpcserver "ap2final_review_service/internal/adapter/grpc"
+	httpserver "ap2final_review_service/internal/adapter/http"
+	"ap2final_review_service/internal/adapter/metrics"
 	mongorepo "ap2final_review_service/internal/adapter/mongo"
+	"ap2final_review_service/internal/adapter/ratelimit"
+	"ap2final_review_service/internal/adapter/tracing"
 	"ap2final_review_service/internal/config"
+	"ap2final_review_service/internal/models"
 	"ap2final_review_service/internal/usecase"
+	"ap2final_review_service/pkg/logger"
+	mongocfg "ap2final_review_service/pkg/mongo"
 	"context"
-	"github.com/sorawaslocked/ap2final_base/pkg/logger"
-	mongocfg "github.com/sorawaslocked/ap2final_base/pkg/mongo"
+	"fmt"
+	"github.com/sorawaslocked/ap2final_base/pkg/security"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
-const serviceName = "review service"
+const (
+	serviceName        = "review service"
+	tracingServiceName = "review-service"
+)
 
 type App struct {
-	grpcServer *grpcserver.Server
-	log        *slog.Logger
+	grpcServer  *grpcserver.Server
+	httpServer  *httpserver.Server
+	adminServer *metrics.Server
+	broadcaster *broadcast.Broadcaster
+	watcher     *mongorepo.ReviewWatcher
+	stopWatcher context.CancelFunc
+	stopTracing func(context.Context) error
+	log         *slog.Logger
 }
 
 func New(
@@ -31,32 +51,197 @@ func New(
 	newLog := log.With(slog.String("op", op))
 	newLog.Info("starting service", slog.String("service", serviceName))
 
+	ratingScale := models.RatingScale{
+		Min:  cfg.Rating.Min,
+		Max:  cfg.Rating.Max,
+		Step: cfg.Rating.Step,
+	}
+	if err := ratingScale.Validate(); err != nil {
+		newLog.Error("invalid rating scale", logger.Err(err))
+		return nil, err
+	}
+
+	aspects := models.Aspects(cfg.Rating.Aspects)
+	if err := aspects.Validate(); err != nil {
+		newLog.Error("invalid rating aspects", logger.Err(err))
+		return nil, err
+	}
+
+	stopTracing, err := tracing.Setup(ctx, cfg.Tracing, tracingServiceName)
+	if err != nil {
+		newLog.Error("error setting up tracing", logger.Err(err))
+		return nil, err
+	}
+
 	newLog.Info("connecting to mongo database", slog.String("uri", cfg.Mongo.URI))
 
-	db, err := mongocfg.NewDB(ctx, cfg.Mongo)
+	db, err := mongocfg.NewDB(ctx, cfg.Mongo, options.Client().SetMonitor(otelmongo.NewMonitor()))
 	if err != nil {
 		newLog.Error("error connecting to mongo database", logger.Err(err))
 		return nil, err
 	}
 
-	reviewRepo := mongorepo.NewReview(db.Connection)
+	m := metrics.New()
+
+	reviewRepo := mongorepo.NewInstrumentedReview(mongorepo.NewReview(db.Connection, aspects), m, log)
+	statsRepo := mongorepo.NewRatingStats(db.Connection)
+
+	scoring := models.WeightedScoring{
+		PriorMean: cfg.Scoring.PriorMean,
+		MinVotes:  cfg.Scoring.MinVotes,
+	}
+
+	broadcaster := broadcast.New(cfg.Events.BufferSize, log)
 
-	reviewUseCase := usecase.NewReviewUseCase(reviewRepo, log)
+	var publisher usecase.ReviewEventPublisher = broadcaster
+	var watcher *mongorepo.ReviewWatcher
 
-	grpcServer := grpcserver.New(cfg.Server.GRPC, log, reviewUseCase)
+	if cfg.Events.ChangeStreams {
+		newLog.Info("publishing review events from mongo change streams")
+
+		publisher = nil
+		watcher = mongorepo.NewReviewWatcher(db.Connection, broadcaster, log)
+	}
+
+	reviewUseCase := usecase.NewTracedReviewUseCase(
+		usecase.NewReviewUseCase(reviewRepo, statsRepo, publisher, broadcaster, scoring, aspects, log),
+	)
+
+	receiptRepo := mongorepo.NewErasureReceipt(db.Connection)
+
+	idempotencyRepo := mongorepo.NewIdempotency(db.Connection)
+	if err := idempotencyRepo.EnsureIndexes(ctx); err != nil {
+		newLog.Error("error creating idempotency key indexes", logger.Err(err))
+		return nil, err
+	}
+
+	privacyUseCase := usecase.NewPrivacyUseCase(
+		reviewRepo,
+		statsRepo,
+		receiptRepo,
+		idempotencyRepo,
+		publisher,
+		[]byte(cfg.Privacy.ReceiptSecret),
+		log,
+	)
+
+	idempotencyUseCase := usecase.NewIdempotencyUseCase(
+		idempotencyRepo,
+		cfg.Idempotency.TTL,
+		cfg.Idempotency.LockTimeout,
+		log,
+	)
+
+	var jwtProvider *security.JWTProvider
+	if cfg.Auth.JWTSecret != "" {
+		jwtProvider = security.NewJWTProvider(cfg.Auth.JWTSecret, 0, 0)
+	} else {
+		newLog.Warn("jwt secret is not configured, admin methods are disabled")
+	}
+
+	var rateLimiter grpcserver.RateLimiter
+	if cfg.RateLimit.Enabled {
+		limiter, err := newRateLimiter(cfg.RateLimit)
+		if err != nil {
+			newLog.Error("invalid rate limits", logger.Err(err))
+			return nil, err
+		}
+		rateLimiter = limiter
+	} else {
+		newLog.Warn("rate limiting is disabled")
+	}
+
+	grpcServer := grpcserver.New(
+		cfg.Server.GRPC,
+		log,
+		reviewUseCase,
+		privacyUseCase,
+		ratingScale,
+		jwtProvider,
+		cfg.Auth.AdminRole,
+		mongorepo.NewHealthCheck(db.Connection),
+		cfg.Health,
+		m,
+		rateLimiter,
+		idempotencyUseCase,
+	)
+
+	httpServer := httpserver.New(cfg.Server.HTTP, log, reviewUseCase, ratingScale, rateLimiter, jwtProvider)
+
+	adminServer := metrics.NewServer(cfg.Server.Admin.Port, log, m)
 
 	return &App{
-		grpcServer: grpcServer,
-		log:        log,
+		grpcServer:  grpcServer,
+		httpServer:  httpServer,
+		adminServer: adminServer,
+		broadcaster: broadcaster,
+		watcher:     watcher,
+		stopTracing: stopTracing,
+		log:         log,
 	}, nil
 }
 
+// newRateLimiter keeps the token buckets in memory, so every replica limits
+// callers on its own. Method limits take the fields they leave unset from
+// the default limit.
+func newRateLimiter(cfg config.RateLimit) (*ratelimit.Limiter, error) {
+	def := ratelimit.Limit{Rate: cfg.Default.Rate, Burst: cfg.Default.Burst}
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+
+	methods := make(map[string]ratelimit.Limit, len(cfg.Methods))
+	for method, override := range cfg.Methods {
+		limit := def
+		if override.Rate != nil {
+			limit.Rate = *override.Rate
+		}
+		if override.Burst != nil {
+			limit.Burst = *override.Burst
+		}
+
+		if err := limit.Validate(); err != nil {
+			return nil, fmt.Errorf("method %s: %w", method, err)
+		}
+
+		methods[method] = limit
+	}
+
+	return ratelimit.New(ratelimit.NewMemoryStore(), def, methods), nil
+}
+
 func (a *App) stop() {
+	if a.stopWatcher != nil {
+		a.stopWatcher()
+	}
+
+	// Ends open review subscriptions, otherwise GracefulStop waits for them.
+	a.broadcaster.Close()
+
+	a.httpServer.Stop()
 	a.grpcServer.Stop()
+	a.adminServer.Stop()
+
+	// Flushes the spans of the requests that just finished.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := a.stopTracing(ctx); err != nil {
+		a.log.Error("error stopping tracing", logger.Err(err))
+	}
 }
 
 func (a *App) Run() {
+	if a.watcher != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		a.stopWatcher = cancel
+
+		go a.watcher.Run(ctx)
+	}
+
 	a.grpcServer.MustRun()
+	a.httpServer.MustRun()
+	a.adminServer.MustRun()
 
 	shutdownCh := make(chan os.Signal, 1)
 	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
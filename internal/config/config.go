@@ -1,22 +1,131 @@
 package config
 
 import (
+	"ap2final_review_service/pkg/mongo"
 	"flag"
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/sorawaslocked/ap2final_base/pkg/grpc"
-	"github.com/sorawaslocked/ap2final_base/pkg/mongo"
 	"os"
+	"time"
 )
 
 type (
 	Config struct {
-		Env    string       `yaml:"env" env-required:"true"`
-		Mongo  mongo.Config `yaml:"mongo" env-required:"true"`
-		Server Server       `yaml:"server" env-required:"true"`
+		Env         string       `yaml:"env" env-required:"true"`
+		Mongo       mongo.Config `yaml:"mongo" env-required:"true"`
+		Server      Server       `yaml:"server" env-required:"true"`
+		Rating      Rating       `yaml:"rating"`
+		Scoring     Scoring      `yaml:"scoring"`
+		Events      Events       `yaml:"events"`
+		Auth        Auth         `yaml:"auth"`
+		Privacy     Privacy      `yaml:"privacy"`
+		Health      Health       `yaml:"health"`
+		Tracing     Tracing      `yaml:"tracing"`
+		RateLimit   RateLimit    `yaml:"rateLimit"`
+		Idempotency Idempotency  `yaml:"idempotency"`
 	}
 
 	Server struct {
-		GRPC grpc.Config `yaml:"grpc" env-required:"true"`
+		GRPC  grpc.Config `yaml:"grpc" env-required:"true"`
+		HTTP  HTTP        `yaml:"http"`
+		Admin Admin       `yaml:"admin"`
+	}
+
+	// HTTP configures the JSON gateway for clients that cannot speak grpc.
+	HTTP struct {
+		Port    int16         `yaml:"port" env:"HTTP_SERVER_PORT" env-default:"8083"`
+		Timeout time.Duration `yaml:"timeout" env:"HTTP_SERVER_TIMEOUT" env-default:"30s"`
+	}
+
+	// Admin serves operational endpoints such as /metrics, which should not
+	// be exposed publicly.
+	Admin struct {
+		Port int16 `yaml:"port" env:"ADMIN_SERVER_PORT" env-default:"9090"`
+	}
+
+	// Rating is the scale clients submit and receive ratings in, and the
+	// aspects that can be rated besides the overall rating.
+	Rating struct {
+		Min  float64 `yaml:"min" env:"RATING_MIN" env-default:"0.5"`
+		Max  float64 `yaml:"max" env:"RATING_MAX" env-default:"5"`
+		Step float64 `yaml:"step" env:"RATING_STEP" env-default:"0.5"`
+
+		Aspects []string `yaml:"aspects" env:"RATING_ASPECTS" env-separator:"," env-default:"story,acting,visuals,sound"`
+	}
+
+	// Scoring configures the Bayesian weighted movie score. PriorMean is in stars.
+	Scoring struct {
+		PriorMean float64 `yaml:"priorMean" env:"SCORING_PRIOR_MEAN" env-default:"3"`
+		MinVotes  int     `yaml:"minVotes" env:"SCORING_MIN_VOTES" env-default:"10"`
+	}
+
+	// Events configures live review events. With ChangeStreams enabled they
+	// are read from Mongo, which requires a replica set.
+	Events struct {
+		BufferSize    int  `yaml:"bufferSize" env:"EVENTS_BUFFER_SIZE" env-default:"64"`
+		ChangeStreams bool `yaml:"changeStreams" env:"EVENTS_CHANGE_STREAMS" env-default:"false"`
+	}
+
+	// Auth verifies the tokens of admin-only methods, which are disabled
+	// while JWTSecret is empty.
+	Auth struct {
+		JWTSecret string `yaml:"jwtSecret" env:"JWT_SECRET"`
+		AdminRole string `yaml:"adminRole" env:"AUTH_ADMIN_ROLE" env-default:"admin"`
+	}
+
+	// Privacy keys the user id hashes of erasure receipts, which are
+	// refused while ReceiptSecret is empty. Changing the secret makes
+	// existing receipts unverifiable.
+	Privacy struct {
+		ReceiptSecret string `yaml:"receiptSecret" env:"PRIVACY_RECEIPT_SECRET"`
+	}
+
+	// Health configures how often the database is pinged for the grpc
+	// health service.
+	Health struct {
+		Interval time.Duration `yaml:"interval" env:"HEALTH_INTERVAL" env-default:"10s"`
+		Timeout  time.Duration `yaml:"timeout" env:"HEALTH_TIMEOUT" env-default:"2s"`
+	}
+
+	// Tracing selects where spans are exported: none, otlp or stdout.
+	// Endpoint is the host:port of the OTLP gRPC collector.
+	Tracing struct {
+		Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+		Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4317"`
+		Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE" env-default:"true"`
+		SampleRatio float64 `yaml:"sampleRatio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+	}
+
+	// RateLimit limits the calls of every user, or of every peer ip for
+	// calls without a valid token. Methods overrides Default per review
+	// service method, keyed by its short name such as Create; http routes
+	// count towards the method they mirror.
+	RateLimit struct {
+		Enabled bool                   `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+		Default Limit                  `yaml:"default"`
+		Methods map[string]MethodLimit `yaml:"methods"`
+	}
+
+	// Idempotency configures how long the responses of requests sent with an
+	// idempotency key are kept, and after how long a request still in
+	// progress is given up on.
+	Idempotency struct {
+		TTL         time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+		LockTimeout time.Duration `yaml:"lockTimeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" env-default:"1m"`
+	}
+
+	// Limit allows Rate calls per second with bursts of up to Burst calls.
+	// A zero Rate disables the limit.
+	Limit struct {
+		Rate  float64 `yaml:"rate" env-default:"10"`
+		Burst int     `yaml:"burst" env-default:"20"`
+	}
+
+	// MethodLimit overrides the fields of the default Limit that it sets.
+	// Defaults are not applied to map values, so unset fields are nil.
+	MethodLimit struct {
+		Rate  *float64 `yaml:"rate"`
+		Burst *int     `yaml:"burst"`
 	}
 )
 
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// RatingBucket aggregates the reviews created within a period starting at Start.
+type RatingBucket struct {
+	Start         time.Time
+	ReviewCount   int
+	AverageRating float64
+}
+
+// UserReviewStats summarizes the active reviews of a user. It has no genre
+// breakdown, e.g. the most reviewed genre, since movies are only known to
+// this service by id.
+type UserReviewStats struct {
+	UserID        string
+	ReviewCount   int
+	AverageRating float64
+	Distribution  map[float64]int // stars rounded to the half star -> count
+	FirstReviewAt time.Time
+	LastReviewAt  time.Time
+	Monthly       []RatingBucket
+}
+
+type BucketSize string
+
+const (
+	BucketDay   BucketSize = "day"
+	BucketWeek  BucketSize = "week"
+	BucketMonth BucketSize = "month"
+)
+
+// TimelineQuery selects the reviews of a movie created in [From, To).
+type TimelineQuery struct {
+	MovieID    string
+	BucketSize BucketSize
+	From       time.Time
+	To         time.Time
+}
+
+var (
+	ErrInvalidBucketSize = errors.New("bucket size must be one of day, week or month")
+	ErrInvalidTimeRange  = errors.New("time range start must be before its end")
+	ErrTooManyBuckets    = errors.New("time range spans too many buckets")
+)
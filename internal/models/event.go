@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type ReviewEventType string
+
+const (
+	ReviewCreated ReviewEventType = "created"
+	ReviewUpdated ReviewEventType = "updated"
+	ReviewDeleted ReviewEventType = "deleted"
+)
+
+type ReviewEvent struct {
+	Type       ReviewEventType
+	Review     Review
+	OccurredAt time.Time
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyInProgress IdempotencyStatus = "in_progress"
+	IdempotencyCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyRecord remembers the outcome of a request sent with an
+// idempotency key. Key is scoped to the caller and method, and RequestHash
+// identifies the payload the key was first used with. UserIDs lists the
+// calling user and the user whose review Response holds, so that erasing a
+// user also removes the responses stored for them.
+type IdempotencyRecord struct {
+	Key         string            `bson:"_id"`
+	RequestHash string            `bson:"request_hash"`
+	Status      IdempotencyStatus `bson:"status"`
+	Response    []byte            `bson:"response,omitempty"`
+	UserIDs     []string          `bson:"user_ids,omitempty"`
+	CreatedAt   time.Time         `bson:"created_at"`
+	ExpiresAt   time.Time         `bson:"expires_at"`
+}
+
+var (
+	ErrInvalidIdempotencyKey = errors.New("idempotency key must be 1 to 128 printable characters")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
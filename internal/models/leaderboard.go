@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const MaxLeaderboardWindow = 365 * 24 * time.Hour
+
+type LeaderboardSort string
+
+const (
+	LeaderboardSortAverage  LeaderboardSort = "average"
+	LeaderboardSortWeighted LeaderboardSort = "weighted"
+	LeaderboardSortVolume   LeaderboardSort = "volume"
+)
+
+// LeaderboardQuery ranks movies by the reviews created within Window before
+// now. A zero Window covers all reviews.
+type LeaderboardQuery struct {
+	SortBy     LeaderboardSort
+	Window     time.Duration
+	MinReviews int
+	Limit      int
+	Offset     int
+}
+
+type LeaderboardEntry struct {
+	Rank int
+	RatingSummary
+}
+
+type Leaderboard struct {
+	Entries []LeaderboardEntry
+	Total   int
+}
+
+var (
+	ErrInvalidLeaderboardSort = errors.New("sort must be one of average, weighted or volume")
+	ErrInvalidWindow          = errors.New("window must be between 0 and 365 days")
+	ErrInvalidOffset          = errors.New("offset cannot be negative")
+	ErrInvalidMinReviews      = errors.New("minimum reviews cannot be negative")
+)
+
+func (q *LeaderboardQuery) Validate() error {
+	switch q.SortBy {
+	case LeaderboardSortAverage, LeaderboardSortWeighted, LeaderboardSortVolume:
+	default:
+		return ErrInvalidLeaderboardSort
+	}
+	if q.Window < 0 || q.Window > MaxLeaderboardWindow {
+		return ErrInvalidWindow
+	}
+	if q.MinReviews < 0 {
+		return ErrInvalidMinReviews
+	}
+	if q.Limit < 1 || q.Limit > MaxLimit {
+		return ErrInvalidLimit
+	}
+	if q.Offset < 0 {
+		return ErrInvalidOffset
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// UserDataExport is everything the review service holds about a user,
+// including soft-deleted reviews. There are no review revisions, votes or
+// reports to include: this service does not store them, so subject access
+// requests must collect them from the services that do, if any.
+type UserDataExport struct {
+	UserID      string
+	GeneratedAt time.Time
+	Reviews     []Review
+}
+
+type ErasureMode string
+
+const (
+	// ErasureDelete removes the user's reviews.
+	ErasureDelete ErasureMode = "delete"
+	// ErasureAnonymize keeps the ratings for aggregates but drops the text
+	// and replaces the user id with a per-review tombstone.
+	ErasureAnonymize ErasureMode = "anonymize"
+)
+
+const ErasedUserIDPrefix = "erased:"
+
+// ErasureReceipt records that a user's data was erased. It stores a keyed
+// hash of the user id so a request can be verified later without keeping
+// the id, and only counts of what was erased, since the hash can be
+// recomputed by anyone holding the secret.
+type ErasureReceipt struct {
+	ID          string      `bson:"_id,omitempty"`
+	UserIDHash  string      `bson:"user_id_hash"`
+	Mode        ErasureMode `bson:"mode"`
+	ReviewCount int         `bson:"review_count"`
+	MovieCount  int         `bson:"movie_count"`
+	RequestedBy string      `bson:"requested_by"`
+	CreatedAt   time.Time   `bson:"created_at"`
+}
+
+var (
+	ErrInvalidErasureMode = errors.New("erasure mode must be delete or anonymize")
+	ErrErasureDisabled    = errors.New("erasure requires a receipt secret")
+)
+
+// HashUserID returns the HMAC-SHA256 of the user id under secret. Unlike a
+// plain hash it cannot be reversed by hashing candidate ids without the
+// secret.
+func HashUserID(secret []byte, userID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+// Ratings are stored normalized to stars between MinStars and MaxStars,
+// independently of the scale clients submit them in.
+const (
+	MinStars = 0.5
+	MaxStars = 5.0
+)
+
+const ratingEpsilon = 1e-9
+
+var ErrInvalidRatingScale = errors.New("invalid rating scale")
+
+// RatingScale is the scale ratings are exchanged with clients in, e.g.
+// 0.5-5 in half-star steps or 1-10 in whole steps.
+type RatingScale struct {
+	Min  float64
+	Max  float64
+	Step float64
+}
+
+var DefaultRatingScale = RatingScale{Min: 0.5, Max: 5, Step: 0.5}
+
+// Validate rejects scales whose lowest value would be stored below MinStars,
+// e.g. 1-100, since ratings at the bottom of such a scale could never be
+// saved.
+func (s RatingScale) Validate() error {
+	if s.Step <= 0 || s.Min <= 0 || s.Max <= s.Min {
+		return ErrInvalidRatingScale
+	}
+	if s.ToStars(s.Min) < MinStars-ratingEpsilon {
+		return ErrInvalidRatingScale
+	}
+	if !isMultiple(s.Min, s.Step) || !isMultiple(s.Max, s.Step) {
+		return ErrInvalidRatingScale
+	}
+	return nil
+}
+
+// Normalize converts a rating given on the scale into stars.
+func (s RatingScale) Normalize(value float64) (float64, error) {
+	if value < s.Min-ratingEpsilon || value > s.Max+ratingEpsilon {
+		return 0, ErrInvalidRating
+	}
+	if !isMultiple(value, s.Step) {
+		return 0, ErrInvalidRating
+	}
+
+	return s.ToStars(value), nil
+}
+
+// ToStars converts a value on the scale into stars without validating it,
+// e.g. for filter bounds.
+func (s RatingScale) ToStars(value float64) float64 {
+	return value * MaxStars / s.Max
+}
+
+// Denormalize converts stars back into the scale.
+func (s RatingScale) Denormalize(stars float64) float64 {
+	return stars * s.Max / MaxStars
+}
+
+func ValidateStars(stars float64) error {
+	if stars < MinStars-ratingEpsilon || stars > MaxStars+ratingEpsilon {
+		return ErrInvalidRating
+	}
+	return nil
+}
+
+func isMultiple(value, step float64) bool {
+	quotient := value / step
+	return math.Abs(quotient-math.Round(quotient)) < ratingEpsilon*math.Max(1, math.Abs(quotient))
+}
+
+// WeightedScoring computes a Bayesian average that pulls movies with few
+// reviews towards PriorMean, with MinVotes acting as the weight of the prior.
+type WeightedScoring struct {
+	PriorMean float64
+	MinVotes  int
+}
+
+func (w WeightedScoring) Score(average float64, count int) float64 {
+	if count+w.MinVotes == 0 {
+		return 0
+	}
+
+	n := float64(count)
+	m := float64(w.MinVotes)
+
+	return (n*average + m*w.PriorMean) / (n + m)
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestRatingScaleNormalize(t *testing.T) {
+	tenPoint := RatingScale{Min: 1, Max: 10, Step: 1}
+
+	tests := []struct {
+		name  string
+		scale RatingScale
+		value float64
+		want  float64
+		err   error
+	}{
+		{name: "lowest half star", scale: DefaultRatingScale, value: 0.5, want: 0.5},
+		{name: "half star", scale: DefaultRatingScale, value: 3.5, want: 3.5},
+		{name: "highest half star", scale: DefaultRatingScale, value: 5, want: 5},
+		{name: "float noise within step", scale: DefaultRatingScale, value: 0.1 + 0.2 + 4.2, want: 4.5},
+		{name: "off half star step", scale: DefaultRatingScale, value: 3.25, err: ErrInvalidRating},
+		{name: "below min", scale: DefaultRatingScale, value: 0, err: ErrInvalidRating},
+		{name: "above max", scale: DefaultRatingScale, value: 5.5, err: ErrInvalidRating},
+		{name: "ten point lowest", scale: tenPoint, value: 1, want: 0.5},
+		{name: "ten point middle", scale: tenPoint, value: 7, want: 3.5},
+		{name: "ten point highest", scale: tenPoint, value: 10, want: 5},
+		{name: "ten point off step", scale: tenPoint, value: 7.5, err: ErrInvalidRating},
+		{name: "ten point above max", scale: tenPoint, value: 11, err: ErrInvalidRating},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.scale.Normalize(tt.value)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Normalize(%v): got error %v, want %v", tt.value, err, tt.err)
+			}
+			if math.Abs(got-tt.want) > ratingEpsilon {
+				t.Fatalf("Normalize(%v): got %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatingScaleDenormalize(t *testing.T) {
+	scale := RatingScale{Min: 1, Max: 10, Step: 1}
+
+	for _, value := range []float64{1, 4, 7, 10} {
+		stars, err := scale.Normalize(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := scale.Denormalize(stars); math.Abs(got-value) > ratingEpsilon {
+			t.Errorf("Denormalize(Normalize(%v)): got %v", value, got)
+		}
+	}
+}
+
+func TestRatingScaleValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		scale RatingScale
+		err   error
+	}{
+		{name: "default", scale: DefaultRatingScale},
+		{name: "ten point", scale: RatingScale{Min: 1, Max: 10, Step: 1}},
+		{name: "ten point half steps", scale: RatingScale{Min: 1, Max: 10, Step: 0.5}},
+		{name: "min below half a star", scale: RatingScale{Min: 1, Max: 100, Step: 1}, err: ErrInvalidRatingScale},
+		{name: "zero step", scale: RatingScale{Min: 0.5, Max: 5}, err: ErrInvalidRatingScale},
+		{name: "negative step", scale: RatingScale{Min: 0.5, Max: 5, Step: -0.5}, err: ErrInvalidRatingScale},
+		{name: "zero min", scale: RatingScale{Max: 5, Step: 0.5}, err: ErrInvalidRatingScale},
+		{name: "max not above min", scale: RatingScale{Min: 5, Max: 5, Step: 0.5}, err: ErrInvalidRatingScale},
+		{name: "min off step", scale: RatingScale{Min: 0.75, Max: 5, Step: 0.5}, err: ErrInvalidRatingScale},
+		{name: "max off step", scale: RatingScale{Min: 1, Max: 9.5, Step: 1}, err: ErrInvalidRatingScale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.scale.Validate(); !errors.Is(err, tt.err) {
+				t.Fatalf("Validate(%+v): got %v, want %v", tt.scale, err, tt.err)
+			}
+		})
+	}
+}
@@ -2,18 +2,42 @@ package models
 
 import (
 	"errors"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+const (
+	MaxTitleLength = 120
+	DefaultLimit   = 10
+	MaxLimit       = 100
+	MaxBatchSize   = 100
+)
+
+// Aspects are the sub-ratings a review may carry in addition to the overall
+// rating, e.g. story or acting. They are configured with the rating scale.
+type Aspects []string
+
+var DefaultAspects = Aspects{"story", "acting", "visuals", "sound"}
+
+// Aspect names end up in document field paths, so they are restricted to
+// short lowercase identifiers.
+var aspectNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
 type Review struct {
-	ID        string    `bson:"_id,omitempty"`
-	UserID    string    `bson:"user_id"`
-	MovieID   string    `bson:"movie_id"`
-	Rating    int       `bson:"rating"` // 1-5 stars
-	Comment   string    `bson:"comment"`
-	CreatedAt time.Time `bson:"created_at"`
-	UpdatedAt time.Time `bson:"updated_at"`
-	IsDeleted bool      `bson:"is_deleted"`
+	ID            string             `bson:"_id,omitempty"`
+	UserID        string             `bson:"user_id"`
+	MovieID       string             `bson:"movie_id"`
+	Rating        float64            `bson:"rating"` // 0.5-5 stars, older documents hold whole stars
+	Title         string             `bson:"title,omitempty"`
+	AspectRatings map[string]float64 `bson:"aspect_ratings,omitempty"` // aspect -> 0.5-5 stars
+	Comment       string             `bson:"comment"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at"`
+	IsDeleted     bool               `bson:"is_deleted"`
+	Version       int64              `bson:"version"` // incremented on every write, 0 for older documents
 }
 
 type ReviewFilter struct {
@@ -21,40 +45,146 @@ type ReviewFilter struct {
 	IDs       []string
 	UserID    *string
 	MovieID   *string
-	Rating    *int
-	MinRating *int
-	MaxRating *int
+	Rating    *float64
+	MinRating *float64
+	MaxRating *float64
+	IsDeleted *bool
+
+	CreatedFrom *time.Time // inclusive
+	CreatedTo   *time.Time // exclusive
+	AfterID     *string    // only reviews with a greater id, for resuming streams
 }
 
 type ReviewUpdateData struct {
-	Rating    *int
-	Comment   *string
-	IsDeleted *bool
+	Rating        *float64
+	Title         *string
+	AspectRatings map[string]float64
+	Comment       *string
+	IsDeleted     *bool
+
+	// Mask, when not nil, lists the paths to write. The use case normalizes
+	// it with NormalizeUpdateMask. Masked fields left nil or empty are
+	// cleared, and fields outside the mask are ignored. Without a mask every
+	// non-nil field is written.
+	Mask []string
+
+	// ExpectedVersion makes the update fail with ErrVersionConflict if the
+	// review has been modified since the client read this version.
+	ExpectedVersion *int64
+}
+
+type RatingSummary struct {
+	MovieID        string
+	AverageRating  float64
+	ReviewCount    int
+	WeightedScore  float64
+	AspectAverages map[string]float64
 }
 
 var (
 	ErrReviewNotFound      = errors.New("review not found")
 	ErrReviewAlreadyExists = errors.New("user has already reviewed this movie")
-	ErrInvalidRating       = errors.New("rating must be between 1 and 5")
+	ErrInvalidRating       = errors.New("rating is outside the rating scale")
 	ErrEmptyComment        = errors.New("comment cannot be empty")
+	ErrTitleTooLong        = errors.New("title must be at most 120 characters")
+	ErrUnknownAspect       = errors.New("unknown rating aspect")
+	ErrInvalidAspectRating = errors.New("aspect rating is outside the rating scale")
+	ErrInvalidAspects      = errors.New("aspects must be unique lowercase names")
+	ErrInvalidLimit        = errors.New("limit must be between 1 and 100")
+	ErrBatchTooLarge       = errors.New("batch must contain at most 100 ids")
+	ErrVersionConflict     = errors.New("review was modified by another request")
+	ErrInvalidVersion      = errors.New("version must be a non-negative integer")
 	ErrInvalidInput        = errors.New("invalid input data")
 )
 
+// ETag renders a review version as a strong entity tag.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseETag reads a version from an entity tag as rendered by ETag. The
+// quotes may be left out.
+func ParseETag(tag string) (int64, error) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) >= 2 && tag[0] == '"' && tag[len(tag)-1] == '"' {
+		tag = tag[1 : len(tag)-1]
+	}
+
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 0 {
+		return 0, ErrInvalidVersion
+	}
+
+	return version, nil
+}
+
+func (a Aspects) Validate() error {
+	seen := make(map[string]bool, len(a))
+	for _, aspect := range a {
+		if !aspectNamePattern.MatchString(aspect) || seen[aspect] {
+			return ErrInvalidAspects
+		}
+		seen[aspect] = true
+	}
+	return nil
+}
+
+func (a Aspects) Contains(aspect string) bool {
+	for _, candidate := range a {
+		if candidate == aspect {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper functions
-func (r *Review) Validate() error {
-	if r.Rating < 1 || r.Rating > 5 {
-		return ErrInvalidRating
+func (r *Review) Validate(aspects Aspects) error {
+	if err := ValidateStars(r.Rating); err != nil {
+		return err
 	}
 	if r.Comment == "" {
 		return ErrEmptyComment
 	}
+	if err := ValidateTitle(r.Title); err != nil {
+		return err
+	}
+	if err := ValidateAspectRatings(r.AspectRatings, aspects); err != nil {
+		return err
+	}
 	if r.UserID == "" || r.MovieID == "" {
 		return ErrInvalidInput
 	}
 	return nil
 }
 
+func ValidateTitle(title string) error {
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return ErrTitleTooLong
+	}
+	return nil
+}
+
+func ValidateAspectRatings(ratings map[string]float64, aspects Aspects) error {
+	for aspect, rating := range ratings {
+		if !aspects.Contains(aspect) {
+			return ErrUnknownAspect
+		}
+		if ValidateStars(rating) != nil {
+			return ErrInvalidAspectRating
+		}
+	}
+	return nil
+}
+
 // Helper for creating pointers
-func IntPtr(i int) *int          { return &i }
-func StringPtr(s string) *string { return &s }
-func BoolPtr(b bool) *bool       { return &b }
+func IntPtr(i int) *int             { return &i }
+func Float64Ptr(f float64) *float64 { return &f }
+func StringPtr(s string) *string    { return &s }
+func BoolPtr(b bool) *bool          { return &b }
+
+// UserMovie identifies the single review a user may write for a movie.
+type UserMovie struct {
+	UserID  string
+	MovieID string
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrMovieStatsNotFound = errors.New("movie rating stats not found")
+
+// MovieRatingStats is the precomputed rating aggregate of a movie's active
+// reviews. The same type describes an increment to apply to stored stats.
+type MovieRatingStats struct {
+	MovieID      string             `bson:"_id"`
+	Count        int                `bson:"count"`
+	Sum          float64            `bson:"sum"`
+	Histogram    map[string]int     `bson:"histogram"` // HistogramKey -> count
+	AspectSums   map[string]float64 `bson:"aspect_sums"`
+	AspectCounts map[string]int     `bson:"aspect_counts"`
+	// Generation counts the writes to the stored stats, so that a rebuild
+	// can tell whether they were incremented while it recomputed them.
+	Generation int64     `bson:"generation"`
+	UpdatedAt  time.Time `bson:"updated_at"`
+}
+
+type MovieStatsMismatch struct {
+	MovieID  string
+	Stored   MovieRatingStats
+	Expected MovieRatingStats
+}
+
+func NewMovieRatingStats(movieID string) MovieRatingStats {
+	return MovieRatingStats{
+		MovieID:      movieID,
+		Histogram:    map[string]int{},
+		AspectSums:   map[string]float64{},
+		AspectCounts: map[string]int{},
+	}
+}
+
+// NewMovieRatingStatsDelta returns the increment that turns stats containing
+// before into stats containing after. Either review may be nil, and deleted
+// reviews are treated as absent.
+func NewMovieRatingStatsDelta(before, after *Review) MovieRatingStats {
+	var delta MovieRatingStats
+	if after != nil {
+		delta = NewMovieRatingStats(after.MovieID)
+	} else if before != nil {
+		delta = NewMovieRatingStats(before.MovieID)
+	}
+
+	if before != nil && !before.IsDeleted {
+		delta.add(*before, -1)
+	}
+	if after != nil && !after.IsDeleted {
+		delta.add(*after, 1)
+	}
+
+	return delta
+}
+
+func (s *MovieRatingStats) Add(review Review) {
+	s.add(review, 1)
+}
+
+func (s *MovieRatingStats) add(review Review, sign int) {
+	if s.Histogram == nil {
+		*s = NewMovieRatingStats(review.MovieID)
+	}
+
+	s.Count += sign
+	s.Sum += float64(sign) * review.Rating
+	s.Histogram[HistogramKey(review.Rating)] += sign
+
+	for aspect, rating := range review.AspectRatings {
+		s.AspectSums[aspect] += float64(sign) * rating
+		s.AspectCounts[aspect] += sign
+	}
+}
+
+func (s MovieRatingStats) IsZero() bool {
+	if s.Count != 0 || s.Sum != 0 {
+		return false
+	}
+	for _, n := range s.Histogram {
+		if n != 0 {
+			return false
+		}
+	}
+	for _, n := range s.AspectCounts {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal compares the aggregated values, ignoring Generation and UpdatedAt.
+func (s MovieRatingStats) Equal(other MovieRatingStats) bool {
+	if s.Count != other.Count || math.Abs(s.Sum-other.Sum) > ratingEpsilon {
+		return false
+	}
+	if !equalCounts(s.Histogram, other.Histogram) || !equalCounts(s.AspectCounts, other.AspectCounts) {
+		return false
+	}
+	for _, aspect := range unionKeys(s.AspectSums, other.AspectSums) {
+		if math.Abs(s.AspectSums[aspect]-other.AspectSums[aspect]) > ratingEpsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func (s MovieRatingStats) Summary() RatingSummary {
+	summary := RatingSummary{
+		MovieID:        s.MovieID,
+		ReviewCount:    s.Count,
+		AspectAverages: map[string]float64{},
+	}
+
+	if s.Count > 0 {
+		summary.AverageRating = s.Sum / float64(s.Count)
+	}
+
+	for aspect, count := range s.AspectCounts {
+		if count > 0 {
+			summary.AspectAverages[aspect] = s.AspectSums[aspect] / float64(count)
+		}
+	}
+
+	return summary
+}
+
+// HistogramKey buckets a rating to the nearest half star, e.g. 4.5 -> "4_5".
+// Dots are avoided because the key is used as a Mongo field name.
+func HistogramKey(stars float64) string {
+	rounded := math.Round(stars*2) / 2
+	return strings.Replace(strconv.FormatFloat(rounded, 'f', 1, 64), ".", "_", 1)
+}
+
+func equalCounts(a, b map[string]int) bool {
+	for key, n := range a {
+		if b[key] != n {
+			return false
+		}
+	}
+	for key, n := range b {
+		if a[key] != n {
+			return false
+		}
+	}
+	return true
+}
+
+func unionKeys(a, b map[string]float64) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewMovieRatingStatsDelta(t *testing.T) {
+	review := Review{MovieID: "m1", Rating: 4, AspectRatings: map[string]float64{"story": 3.5}}
+	rerated := Review{MovieID: "m1", Rating: 2.5, AspectRatings: map[string]float64{"acting": 5}}
+	deleted := review
+	deleted.IsDeleted = true
+
+	tests := []struct {
+		name          string
+		before, after *Review
+		want          MovieRatingStats
+	}{
+		{
+			name:  "create",
+			after: &review,
+			want: MovieRatingStats{
+				MovieID:      "m1",
+				Count:        1,
+				Sum:          4,
+				Histogram:    map[string]int{"4_0": 1},
+				AspectSums:   map[string]float64{"story": 3.5},
+				AspectCounts: map[string]int{"story": 1},
+			},
+		},
+		{
+			name:   "update",
+			before: &review,
+			after:  &rerated,
+			want: MovieRatingStats{
+				MovieID:      "m1",
+				Sum:          -1.5,
+				Histogram:    map[string]int{"4_0": -1, "2_5": 1},
+				AspectSums:   map[string]float64{"story": -3.5, "acting": 5},
+				AspectCounts: map[string]int{"story": -1, "acting": 1},
+			},
+		},
+		{
+			name:   "soft delete",
+			before: &review,
+			after:  &deleted,
+			want: MovieRatingStats{
+				MovieID:      "m1",
+				Count:        -1,
+				Sum:          -4,
+				Histogram:    map[string]int{"4_0": -1},
+				AspectSums:   map[string]float64{"story": -3.5},
+				AspectCounts: map[string]int{"story": -1},
+			},
+		},
+		{
+			name:   "hard delete",
+			before: &review,
+			want: MovieRatingStats{
+				MovieID:      "m1",
+				Count:        -1,
+				Sum:          -4,
+				Histogram:    map[string]int{"4_0": -1},
+				AspectSums:   map[string]float64{"story": -3.5},
+				AspectCounts: map[string]int{"story": -1},
+			},
+		},
+		{
+			name:   "update of a deleted review",
+			before: &deleted,
+			after:  &deleted,
+			want:   NewMovieRatingStats("m1"),
+		},
+		{
+			name: "no review",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewMovieRatingStatsDelta(tt.before, tt.after)
+			if got.MovieID != tt.want.MovieID || !got.Equal(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			if got.IsZero() != tt.want.IsZero() {
+				t.Fatalf("IsZero: got %t, want %t", got.IsZero(), tt.want.IsZero())
+			}
+		})
+	}
+}
+
+func TestMovieRatingStatsEqual(t *testing.T) {
+	base := MovieRatingStats{
+		MovieID:      "m1",
+		Count:        2,
+		Sum:          7.5,
+		Histogram:    map[string]int{"4_0": 1, "3_5": 1},
+		AspectSums:   map[string]float64{"story": 6},
+		AspectCounts: map[string]int{"story": 2},
+	}
+
+	tests := []struct {
+		name  string
+		other func(s *MovieRatingStats)
+		want  bool
+	}{
+		{name: "same", other: func(s *MovieRatingStats) {}, want: true},
+		{name: "generation and time ignored", other: func(s *MovieRatingStats) {
+			s.Generation = 9
+			s.UpdatedAt = time.Now()
+		}, want: true},
+		{name: "float noise ignored", other: func(s *MovieRatingStats) { s.Sum = 0.1 + 0.2 + 7.2 }, want: true},
+		{name: "zero entries ignored", other: func(s *MovieRatingStats) {
+			s.Histogram["5_0"] = 0
+			s.AspectCounts["acting"] = 0
+			s.AspectSums["acting"] = 0
+		}, want: true},
+		{name: "count", other: func(s *MovieRatingStats) { s.Count = 3 }},
+		{name: "sum", other: func(s *MovieRatingStats) { s.Sum = 8 }},
+		{name: "histogram bucket", other: func(s *MovieRatingStats) { s.Histogram = map[string]int{"4_0": 2} }},
+		{name: "aspect count", other: func(s *MovieRatingStats) { s.AspectCounts["story"] = 1 }},
+		{name: "aspect sum", other: func(s *MovieRatingStats) { s.AspectSums["story"] = 6.5 }},
+		{name: "extra aspect", other: func(s *MovieRatingStats) { s.AspectSums["acting"] = 4 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := base
+			other.Histogram = copyCounts(base.Histogram)
+			other.AspectCounts = copyCounts(base.AspectCounts)
+			other.AspectSums = map[string]float64{}
+			for aspect, sum := range base.AspectSums {
+				other.AspectSums[aspect] = sum
+			}
+			tt.other(&other)
+
+			if got := base.Equal(other); got != tt.want {
+				t.Fatalf("base.Equal(other): got %t, want %t", got, tt.want)
+			}
+			if got := other.Equal(base); got != tt.want {
+				t.Fatalf("other.Equal(base): got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogramKey(t *testing.T) {
+	tests := []struct {
+		stars float64
+		want  string
+	}{
+		{stars: 0.5, want: "0_5"},
+		{stars: 4, want: "4_0"},
+		{stars: 4.5, want: "4_5"},
+		{stars: 4.7, want: "4_5"},
+		{stars: 4.8, want: "5_0"},
+	}
+
+	for _, tt := range tests {
+		if got := HistogramKey(tt.stars); got != tt.want {
+			t.Errorf("HistogramKey(%v): got %q, want %q", tt.stars, got, tt.want)
+		}
+	}
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	res := make(map[string]int, len(counts))
+	for key, n := range counts {
+		res[key] = n
+	}
+	return res
+}
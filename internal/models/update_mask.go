@@ -0,0 +1,156 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Paths of a review that an update mask may name. A single aspect is named
+// as aspect_ratings.<aspect>.
+const (
+	UpdatePathRating        = "rating"
+	UpdatePathTitle         = "title"
+	UpdatePathAspectRatings = "aspect_ratings"
+	UpdatePathComment       = "comment"
+	UpdatePathIsDeleted     = "is_deleted"
+)
+
+var MutableReviewPaths = []string{
+	UpdatePathRating,
+	UpdatePathTitle,
+	UpdatePathAspectRatings,
+	UpdatePathComment,
+	UpdatePathIsDeleted,
+}
+
+var (
+	ErrInvalidUpdateMask = errors.New("update mask is empty or names a field that cannot be updated")
+	ErrFieldNotClearable = errors.New("rating and comment cannot be cleared")
+)
+
+// NormalizeUpdateMask checks paths against MutableReviewPaths and returns
+// them sorted without duplicates. Single aspects are dropped when the mask
+// also names the whole aspect_ratings map. An empty mask is rejected, since
+// it would bump the version of a review without writing anything.
+func NormalizeUpdateMask(paths []string, aspects Aspects) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, ErrInvalidUpdateMask
+	}
+
+	seen := make(map[string]bool, len(paths))
+
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+
+		if aspect, ok := strings.CutPrefix(path, UpdatePathAspectRatings+"."); ok {
+			if !aspects.Contains(aspect) {
+				return nil, ErrUnknownAspect
+			}
+		} else if !isMutableReviewPath(path) {
+			return nil, ErrInvalidUpdateMask
+		}
+
+		seen[path] = true
+	}
+
+	res := make([]string, 0, len(seen))
+	for path := range seen {
+		if strings.HasPrefix(path, UpdatePathAspectRatings+".") && seen[UpdatePathAspectRatings] {
+			continue
+		}
+		res = append(res, path)
+	}
+
+	sort.Strings(res)
+
+	return res, nil
+}
+
+func isMutableReviewPath(path string) bool {
+	for _, p := range MutableReviewPaths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Writes reports whether the update writes path, which is one of
+// MutableReviewPaths.
+func (u ReviewUpdateData) Writes(path string) bool {
+	if u.Mask != nil {
+		for _, p := range u.Mask {
+			if p == path {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch path {
+	case UpdatePathRating:
+		return u.Rating != nil
+	case UpdatePathTitle:
+		return u.Title != nil
+	case UpdatePathAspectRatings:
+		return len(u.AspectRatings) > 0
+	case UpdatePathComment:
+		return u.Comment != nil
+	case UpdatePathIsDeleted:
+		return u.IsDeleted != nil
+	default:
+		return false
+	}
+}
+
+// writesAspect reports whether the update writes the rating of aspect.
+func (u ReviewUpdateData) writesAspect(aspect string) bool {
+	if u.Mask == nil {
+		_, ok := u.AspectRatings[aspect]
+		return ok
+	}
+
+	return u.Writes(UpdatePathAspectRatings) || u.Writes(UpdatePathAspectRatings+"."+aspect)
+}
+
+// Validate checks the values the update writes.
+func (u ReviewUpdateData) Validate(aspects Aspects) error {
+	if u.Writes(UpdatePathRating) {
+		if u.Rating == nil {
+			return ErrFieldNotClearable
+		}
+		if err := ValidateStars(*u.Rating); err != nil {
+			return err
+		}
+	}
+
+	if u.Writes(UpdatePathComment) {
+		if u.Comment == nil {
+			return ErrFieldNotClearable
+		}
+		if *u.Comment == "" {
+			return ErrEmptyComment
+		}
+	}
+
+	if u.Writes(UpdatePathTitle) && u.Title != nil {
+		if err := ValidateTitle(*u.Title); err != nil {
+			return err
+		}
+	}
+
+	for aspect, rating := range u.AspectRatings {
+		if !u.writesAspect(aspect) {
+			continue
+		}
+		if !aspects.Contains(aspect) {
+			return ErrUnknownAspect
+		}
+		if ValidateStars(rating) != nil {
+			return ErrInvalidAspectRating
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeUpdateMask(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+		err   error
+	}{
+		{name: "single path", paths: []string{"title"}, want: []string{"title"}},
+		{name: "sorted", paths: []string{"rating", "comment", "is_deleted"}, want: []string{"comment", "is_deleted", "rating"}},
+		{name: "duplicates and spaces", paths: []string{"title", " title ", "rating"}, want: []string{"rating", "title"}},
+		{name: "single aspect", paths: []string{"aspect_ratings.story"}, want: []string{"aspect_ratings.story"}},
+		{
+			name:  "single aspect covered by the map",
+			paths: []string{"aspect_ratings.story", "aspect_ratings", "aspect_ratings.acting"},
+			want:  []string{"aspect_ratings"},
+		},
+		{name: "empty", paths: []string{}, err: ErrInvalidUpdateMask},
+		{name: "blank path", paths: []string{""}, err: ErrInvalidUpdateMask},
+		{name: "immutable path", paths: []string{"title", "user_id"}, err: ErrInvalidUpdateMask},
+		{name: "unknown path", paths: []string{"ratings"}, err: ErrInvalidUpdateMask},
+		{name: "unknown aspect", paths: []string{"aspect_ratings.plot"}, err: ErrUnknownAspect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeUpdateMask(tt.paths, DefaultAspects)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewUpdateDataValidate(t *testing.T) {
+	rating := 4.5
+	invalidRating := 7.0
+	comment := "Great pacing."
+	empty := ""
+	longTitle := strings.Repeat("a", MaxTitleLength+1)
+
+	tests := []struct {
+		name   string
+		update ReviewUpdateData
+		err    error
+	}{
+		{name: "no fields", update: ReviewUpdateData{}},
+		{name: "rating", update: ReviewUpdateData{Rating: &rating}},
+		{name: "invalid rating", update: ReviewUpdateData{Rating: &invalidRating}, err: ErrInvalidRating},
+		{name: "empty comment", update: ReviewUpdateData{Comment: &empty}, err: ErrEmptyComment},
+		{name: "title too long", update: ReviewUpdateData{Title: &longTitle}, err: ErrTitleTooLong},
+		{
+			name:   "aspect ratings",
+			update: ReviewUpdateData{AspectRatings: map[string]float64{"story": 4, "acting": 0.5}},
+		},
+		{
+			name:   "unknown aspect",
+			update: ReviewUpdateData{AspectRatings: map[string]float64{"plot": 4}},
+			err:    ErrUnknownAspect,
+		},
+		{
+			name:   "invalid aspect rating",
+			update: ReviewUpdateData{AspectRatings: map[string]float64{"story": 5.5}},
+			err:    ErrInvalidAspectRating,
+		},
+		{
+			name:   "masked rating cleared",
+			update: ReviewUpdateData{Mask: []string{"rating"}},
+			err:    ErrFieldNotClearable,
+		},
+		{
+			name:   "masked comment written",
+			update: ReviewUpdateData{Comment: &comment, Mask: []string{"comment", "title"}},
+		},
+		{
+			name:   "masked comment cleared without value",
+			update: ReviewUpdateData{Mask: []string{"comment"}},
+			err:    ErrFieldNotClearable,
+		},
+		{name: "masked title cleared", update: ReviewUpdateData{Mask: []string{"title"}}},
+		{
+			name:   "unmasked invalid rating ignored",
+			update: ReviewUpdateData{Rating: &invalidRating, Mask: []string{"title"}},
+		},
+		{
+			name:   "unmasked aspect ignored",
+			update: ReviewUpdateData{AspectRatings: map[string]float64{"story": 4, "acting": 9}, Mask: []string{"aspect_ratings.story"}},
+		},
+		{
+			name:   "masked aspect checked",
+			update: ReviewUpdateData{AspectRatings: map[string]float64{"acting": 9}, Mask: []string{"aspect_ratings.acting"}},
+			err:    ErrInvalidAspectRating,
+		},
+		{
+			name:   "whole aspect map checked",
+			update: ReviewUpdateData{AspectRatings: map[string]float64{"acting": 9}, Mask: []string{"aspect_ratings"}},
+			err:    ErrInvalidAspectRating,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.update.Validate(DefaultAspects); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"ap2final_review_service/internal/models"
+	"ap2final_review_service/pkg/logger"
+)
+
+type idempotencyUseCase struct {
+	repo        IdempotencyRepository
+	ttl         time.Duration
+	lockTimeout time.Duration
+	log         *slog.Logger
+}
+
+// NewIdempotencyUseCase keeps the outcome of a request for ttl. A request
+// still in progress after lockTimeout is assumed to have died with its
+// replica, and its key can be used again.
+func NewIdempotencyUseCase(
+	repo IdempotencyRepository,
+	ttl time.Duration,
+	lockTimeout time.Duration,
+	log *slog.Logger,
+) IdempotencyUseCase {
+	return &idempotencyUseCase{
+		repo:        repo,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+		log:         log,
+	}
+}
+
+// Execute runs fn once per key and returns its response. A repeated key
+// returns the stored response with replayed set, as long as requestHash
+// matches the first request. Failed requests are not stored, so they can be
+// retried with the same key. userID is the calling user, if any, and fn
+// returns the user whose data the response holds, so that the record is
+// erased with either of them.
+func (uc *idempotencyUseCase) Execute(
+	ctx context.Context,
+	key, requestHash, userID string,
+	fn func(ctx context.Context) (response []byte, subjectID string, err error),
+) ([]byte, bool, error) {
+	now := time.Now()
+
+	record := &models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      models.IdempotencyInProgress,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(uc.ttl),
+	}
+
+	if userID != "" {
+		record.UserIDs = []string{userID}
+	}
+
+	existing, reserved, err := uc.repo.Reserve(ctx, record, now.Add(-uc.lockTimeout))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !reserved {
+		switch {
+		case existing.RequestHash != requestHash:
+			return nil, false, models.ErrIdempotencyKeyReused
+		case existing.Status != models.IdempotencyCompleted:
+			return nil, false, models.ErrIdempotencyInProgress
+		default:
+			return existing.Response, true, nil
+		}
+	}
+
+	response, subjectID, err := fn(ctx)
+	if err != nil {
+		// The request may have been cancelled, the key still has to be released.
+		if releaseErr := uc.repo.Release(context.WithoutCancel(ctx), key); releaseErr != nil {
+			logger.FromContext(ctx, uc.log).Error("failed to release idempotency key", "error", releaseErr)
+		}
+
+		return nil, false, err
+	}
+
+	if err := uc.repo.Complete(context.WithoutCancel(ctx), key, response, subjectID); err != nil {
+		// The request succeeded, only a retry would run it again.
+		logger.FromContext(ctx, uc.log).Error("failed to store idempotent response", "error", err)
+	}
+
+	return response, false, nil
+}
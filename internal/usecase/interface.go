@@ -3,25 +3,82 @@ package usecase
 import (
 	"ap2final_review_service/internal/models"
 	"context"
+	"time"
 )
 
 type ReviewUseCase interface {
 	Create(ctx context.Context, review models.Review) (models.Review, error)
 	GetByID(ctx context.Context, id string) (models.Review, error)
+	BatchGet(ctx context.Context, ids []string) ([]models.Review, []string, error)
 	GetAll(ctx context.Context) ([]models.Review, error)
+	StreamReviews(ctx context.Context, filter models.ReviewFilter, fn func(models.Review) error) error
 	GetByUserID(ctx context.Context, userID string) ([]models.Review, error)
 	GetByMovieID(ctx context.Context, movieID string) ([]models.Review, error)
 	UpdateByID(ctx context.Context, id string, update models.ReviewUpdateData) (models.Review, error)
-	DeleteByID(ctx context.Context, id string) (models.Review, error)
-	GetMovieAverageRating(ctx context.Context, movieID string) (float64, error)
+	DeleteByID(ctx context.Context, id string, expectedVersion *int64) (models.Review, error)
+	GetMovieAverageRating(ctx context.Context, movieID string) (models.RatingSummary, error)
+	GetTopRatedMovies(ctx context.Context, limit int) ([]models.RatingSummary, error)
+	GetMovieLeaderboard(ctx context.Context, query models.LeaderboardQuery) (models.Leaderboard, error)
+	GetUserReviewStats(ctx context.Context, userID string) (models.UserReviewStats, error)
+	GetMovieRatingTimeline(ctx context.Context, query models.TimelineQuery) ([]models.RatingBucket, error)
+	SubscribeMovieReviews(ctx context.Context, movieID string) (<-chan models.ReviewEvent, func(), error)
+}
+
+type PrivacyUseCase interface {
+	ExportUserData(ctx context.Context, userID string) (models.UserDataExport, error)
+	EraseUserData(ctx context.Context, userID string, mode models.ErasureMode, requestedBy string) (models.ErasureReceipt, error)
 }
 
 type ReviewRepository interface {
 	Create(ctx context.Context, review *models.Review) (models.Review, error)
 	FindByID(ctx context.Context, id string) (models.Review, error)
 	Find(ctx context.Context, filter models.ReviewFilter) ([]models.Review, error)
+	Stream(ctx context.Context, filter models.ReviewFilter, fn func(models.Review) error) error
 	Update(ctx context.Context, id string, update models.ReviewUpdateData) (models.Review, error)
 	Delete(ctx context.Context, id string) (models.Review, error)
+	DeleteByUserID(ctx context.Context, userID string) (int, error)
+	AnonymizeByUserID(ctx context.Context, userID string) (int, error)
 	CheckUserReviewExists(ctx context.Context, userID, movieID string) (bool, error)
-	GetAverageRating(ctx context.Context, movieID string) (float64, error)
+	GetAverageRating(ctx context.Context, movieID string) (models.RatingSummary, error)
+	GetMovieLeaderboard(
+		ctx context.Context,
+		query models.LeaderboardQuery,
+		since *time.Time,
+		scoring models.WeightedScoring,
+	) (models.Leaderboard, error)
+	GetUserReviewStats(ctx context.Context, userID string) (models.UserReviewStats, error)
+	GetMovieRatingTimeline(ctx context.Context, query models.TimelineQuery) ([]models.RatingBucket, error)
+}
+
+type RatingStatsRepository interface {
+	Apply(ctx context.Context, delta models.MovieRatingStats) error
+	FindByMovieID(ctx context.Context, movieID string) (models.MovieRatingStats, error)
+	Invalidate(ctx context.Context, movieID string) error
+}
+
+type ErasureReceiptRepository interface {
+	Create(ctx context.Context, receipt *models.ErasureReceipt) (models.ErasureReceipt, error)
+}
+
+type ReviewEventPublisher interface {
+	Publish(event models.ReviewEvent)
+}
+
+type ReviewEventSubscriber interface {
+	Subscribe(movieID string) (<-chan models.ReviewEvent, func())
+}
+
+type IdempotencyUseCase interface {
+	Execute(
+		ctx context.Context,
+		key, requestHash, userID string,
+		fn func(ctx context.Context) (response []byte, subjectID string, err error),
+	) ([]byte, bool, error)
+}
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (models.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, response []byte, userID string) error
+	Release(ctx context.Context, key string) error
+	DeleteByUserID(ctx context.Context, userID string) (int, error)
 }
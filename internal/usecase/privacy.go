@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"ap2final_review_service/internal/models"
+	"ap2final_review_service/pkg/logger"
+)
+
+type privacyUseCase struct {
+	repo            ReviewRepository
+	statsRepo       RatingStatsRepository
+	receiptRepo     ErasureReceiptRepository
+	idempotencyRepo IdempotencyRepository
+	publisher       ReviewEventPublisher
+	receiptSecret   []byte
+	log             *slog.Logger
+}
+
+// NewPrivacyUseCase creates the privacy use case. publisher may be nil when
+// review events are produced elsewhere, and erasure is refused while
+// receiptSecret is empty.
+func NewPrivacyUseCase(
+	repo ReviewRepository,
+	statsRepo RatingStatsRepository,
+	receiptRepo ErasureReceiptRepository,
+	idempotencyRepo IdempotencyRepository,
+	publisher ReviewEventPublisher,
+	receiptSecret []byte,
+	log *slog.Logger,
+) PrivacyUseCase {
+	return &privacyUseCase{
+		repo:            repo,
+		statsRepo:       statsRepo,
+		receiptRepo:     receiptRepo,
+		idempotencyRepo: idempotencyRepo,
+		publisher:       publisher,
+		receiptSecret:   receiptSecret,
+		log:             log,
+	}
+}
+
+func (uc *privacyUseCase) ExportUserData(ctx context.Context, userID string) (models.UserDataExport, error) {
+	if userID == "" {
+		return models.UserDataExport{}, models.ErrInvalidInput
+	}
+
+	reviews, err := uc.repo.Find(ctx, models.ReviewFilter{UserID: &userID})
+	if err != nil {
+		logger.FromContext(ctx, uc.log).Error("failed to export user data", "user_id", userID, "error", err)
+		return models.UserDataExport{}, err
+	}
+
+	return models.UserDataExport{
+		UserID:      userID,
+		GeneratedAt: time.Now(),
+		Reviews:     reviews,
+	}, nil
+}
+
+// EraseUserData deletes or anonymizes all reviews of a user, removes the
+// idempotent responses stored for them, takes deleted reviews out of the
+// rating stats of their movies, publishes the deletes or updates of the user's active
+// reviews and records a receipt.
+func (uc *privacyUseCase) EraseUserData(
+	ctx context.Context,
+	userID string,
+	mode models.ErasureMode,
+	requestedBy string,
+) (models.ErasureReceipt, error) {
+	if userID == "" {
+		return models.ErasureReceipt{}, models.ErrInvalidInput
+	}
+
+	if mode != models.ErasureDelete && mode != models.ErasureAnonymize {
+		return models.ErasureReceipt{}, models.ErrInvalidErasureMode
+	}
+
+	if len(uc.receiptSecret) == 0 {
+		return models.ErasureReceipt{}, models.ErrErasureDisabled
+	}
+
+	reviews, err := uc.repo.Find(ctx, models.ReviewFilter{UserID: &userID})
+	if err != nil {
+		return models.ErasureReceipt{}, err
+	}
+
+	movies := make(map[string]bool, len(reviews))
+	for _, review := range reviews {
+		movies[review.MovieID] = true
+	}
+
+	var erased int
+	switch mode {
+	case models.ErasureDelete:
+		erased, err = uc.repo.DeleteByUserID(ctx, userID)
+	case models.ErasureAnonymize:
+		erased, err = uc.repo.AnonymizeByUserID(ctx, userID)
+	}
+	if err != nil {
+		logger.FromContext(ctx, uc.log).Error("failed to erase user data", "mode", mode, "error", err)
+		return models.ErasureReceipt{}, err
+	}
+
+	if _, err := uc.idempotencyRepo.DeleteByUserID(ctx, userID); err != nil {
+		logger.FromContext(ctx, uc.log).Error("failed to erase idempotent responses", "error", err)
+		return models.ErasureReceipt{}, err
+	}
+
+	// Anonymizing keeps the ratings, so only deletes change the stats.
+	if mode == models.ErasureDelete {
+		uc.removeStats(ctx, reviews)
+	}
+
+	uc.publishErasure(ctx, mode, reviews)
+
+	receipt, err := uc.receiptRepo.Create(ctx, &models.ErasureReceipt{
+		UserIDHash:  models.HashUserID(uc.receiptSecret, userID),
+		Mode:        mode,
+		ReviewCount: erased,
+		MovieCount:  len(movies),
+		RequestedBy: requestedBy,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		logger.FromContext(ctx, uc.log).Error("failed to record erasure receipt", "mode", mode, "error", err)
+		return models.ErasureReceipt{}, err
+	}
+
+	return receipt, nil
+}
+
+// removeStats takes the deleted reviews out of the rating stats of their
+// movies. Like a review delete, a failure invalidates the movie's stats
+// instead of failing the erasure.
+func (uc *privacyUseCase) removeStats(ctx context.Context, reviews []models.Review) {
+	for _, review := range reviews {
+		delta := models.NewMovieRatingStatsDelta(&review, nil)
+
+		if err := uc.statsRepo.Apply(ctx, delta); err != nil {
+			invalidateStats(ctx, uc.statsRepo, logger.FromContext(ctx, uc.log), review.MovieID, err)
+		}
+	}
+}
+
+// publishErasure announces the erasure of the user's active reviews: deletes
+// in delete mode, and the anonymized reviews in anonymize mode so consumers
+// can drop the text they hold.
+func (uc *privacyUseCase) publishErasure(ctx context.Context, mode models.ErasureMode, reviews []models.Review) {
+	if uc.publisher == nil {
+		return
+	}
+
+	ids := make([]string, 0, len(reviews))
+	for _, review := range reviews {
+		if !review.IsDeleted {
+			ids = append(ids, review.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+
+	eventType := models.ReviewDeleted
+
+	if mode == models.ErasureAnonymize {
+		eventType = models.ReviewUpdated
+
+		var err error
+		reviews, err = uc.repo.Find(ctx, models.ReviewFilter{IDs: ids, IsDeleted: models.BoolPtr(false)})
+		if err != nil {
+			logger.FromContext(ctx, uc.log).Error("failed to load anonymized reviews for events", "error", err)
+			return
+		}
+	}
+
+	now := time.Now()
+
+	for _, review := range reviews {
+		if review.IsDeleted {
+			continue
+		}
+
+		uc.publisher.Publish(models.ReviewEvent{
+			Type:       eventType,
+			Review:     review,
+			OccurredAt: now,
+		})
+	}
+}
@@ -2,26 +2,57 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"time"
 
 	"ap2final_review_service/internal/models"
+	"ap2final_review_service/pkg/logger"
+)
+
+const (
+	defaultTimelineDays = 30
+	maxTimelineBuckets  = 366
+
+	// maxWriteAttempts bounds the retries of unversioned updates and deletes
+	// that lose a race with a concurrent write.
+	maxWriteAttempts = 3
 )
 
 type reviewUseCase struct {
-	repo ReviewRepository
-	log  *slog.Logger
+	repo       ReviewRepository
+	statsRepo  RatingStatsRepository
+	publisher  ReviewEventPublisher
+	subscriber ReviewEventSubscriber
+	scoring    models.WeightedScoring
+	aspects    models.Aspects
+	log        *slog.Logger
 }
 
-func NewReviewUseCase(repo ReviewRepository, log *slog.Logger) ReviewUseCase {
+// NewReviewUseCase creates the review use case. publisher may be nil when
+// review events are produced elsewhere, e.g. from Mongo change streams.
+func NewReviewUseCase(
+	repo ReviewRepository,
+	statsRepo RatingStatsRepository,
+	publisher ReviewEventPublisher,
+	subscriber ReviewEventSubscriber,
+	scoring models.WeightedScoring,
+	aspects models.Aspects,
+	log *slog.Logger,
+) ReviewUseCase {
 	return &reviewUseCase{
-		repo: repo,
-		log:  log,
+		repo:       repo,
+		statsRepo:  statsRepo,
+		publisher:  publisher,
+		subscriber: subscriber,
+		scoring:    scoring,
+		aspects:    aspects,
+		log:        log,
 	}
 }
 
 func (uc *reviewUseCase) Create(ctx context.Context, review models.Review) (models.Review, error) {
-	if err := review.Validate(); err != nil {
+	if err := review.Validate(uc.aspects); err != nil {
 		return models.Review{}, err
 	}
 
@@ -40,10 +71,13 @@ func (uc *reviewUseCase) Create(ctx context.Context, review models.Review) (mode
 
 	createdReview, err := uc.repo.Create(ctx, &review)
 	if err != nil {
-		uc.log.Error("failed to create review", "error", err)
+		logger.FromContext(ctx, uc.log).Error("failed to create review", "error", err)
 		return models.Review{}, err
 	}
 
+	uc.applyStats(ctx, nil, &createdReview)
+	uc.publish(models.ReviewCreated, createdReview)
+
 	return createdReview, nil
 }
 
@@ -60,6 +94,57 @@ func (uc *reviewUseCase) GetByID(ctx context.Context, id string) (models.Review,
 	return review, nil
 }
 
+// BatchGet returns the active reviews with the given ids in request order,
+// together with the ids that were not found. Duplicate ids are returned once.
+func (uc *reviewUseCase) BatchGet(ctx context.Context, ids []string) ([]models.Review, []string, error) {
+	// Checked before deduplicating so oversized requests are rejected
+	// without building a set of every id.
+	if len(ids) > models.MaxBatchSize {
+		return nil, nil, models.ErrBatchTooLarge
+	}
+
+	uniqueIDs := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" {
+			return nil, nil, models.ErrInvalidInput
+		}
+		if !seen[id] {
+			seen[id] = true
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+
+	if len(uniqueIDs) == 0 {
+		return nil, nil, nil
+	}
+
+	reviews, err := uc.repo.Find(ctx, models.ReviewFilter{
+		IDs:       uniqueIDs,
+		IsDeleted: models.BoolPtr(false),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[string]models.Review, len(reviews))
+	for _, review := range reviews {
+		byID[review.ID] = review
+	}
+
+	found := make([]models.Review, 0, len(uniqueIDs))
+	var notFound []string
+	for _, id := range uniqueIDs {
+		if review, ok := byID[id]; ok {
+			found = append(found, review)
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+
+	return found, notFound, nil
+}
+
 func (uc *reviewUseCase) GetAll(ctx context.Context) ([]models.Review, error) {
 	reviews, err := uc.repo.Find(ctx, models.ReviewFilter{})
 	if err != nil {
@@ -76,6 +161,16 @@ func (uc *reviewUseCase) GetAll(ctx context.Context) ([]models.Review, error) {
 	return activeReviews, nil
 }
 
+// StreamReviews passes the matching reviews to fn one at a time. Deleted
+// reviews are skipped unless the filter explicitly asks for them.
+func (uc *reviewUseCase) StreamReviews(ctx context.Context, filter models.ReviewFilter, fn func(models.Review) error) error {
+	if filter.IsDeleted == nil {
+		filter.IsDeleted = models.BoolPtr(false)
+	}
+
+	return uc.repo.Stream(ctx, filter, fn)
+}
+
 func (uc *reviewUseCase) GetByUserID(ctx context.Context, userID string) ([]models.Review, error) {
 	reviews, err := uc.repo.Find(ctx, models.ReviewFilter{UserID: &userID})
 	if err != nil {
@@ -109,62 +204,253 @@ func (uc *reviewUseCase) GetByMovieID(ctx context.Context, movieID string) ([]mo
 }
 
 func (uc *reviewUseCase) UpdateByID(ctx context.Context, id string, update models.ReviewUpdateData) (models.Review, error) {
-	existing, err := uc.repo.FindByID(ctx, id)
-	if err != nil {
+	if update.Mask != nil {
+		var err error
+		if update.Mask, err = models.NormalizeUpdateMask(update.Mask, uc.aspects); err != nil {
+			return models.Review{}, err
+		}
+	}
+
+	if err := update.Validate(uc.aspects); err != nil {
 		return models.Review{}, err
 	}
 
-	if existing.IsDeleted {
-		return models.Review{}, models.ErrReviewNotFound
+	existing, updatedReview, err := uc.write(ctx, id, update)
+	if errors.Is(err, models.ErrReviewNotFound) || errors.Is(err, models.ErrVersionConflict) {
+		return models.Review{}, err
+	}
+	if err != nil {
+		logger.FromContext(ctx, uc.log).Error("failed to update review", "review_id", id, "error", err)
+		return models.Review{}, err
 	}
 
-	if update.Rating != nil {
-		if *update.Rating < 1 || *update.Rating > 5 {
-			return models.Review{}, models.ErrInvalidRating
-		}
+	uc.applyStats(ctx, &existing, &updatedReview)
+
+	if updatedReview.IsDeleted {
+		uc.publish(models.ReviewDeleted, updatedReview)
+	} else {
+		uc.publish(models.ReviewUpdated, updatedReview)
 	}
 
-	if update.Comment != nil && *update.Comment == "" {
-		return models.Review{}, models.ErrEmptyComment
+	return updatedReview, nil
+}
+
+// DeleteByID soft-deletes the review. With an expected version it fails with
+// ErrVersionConflict if the review has been modified since.
+func (uc *reviewUseCase) DeleteByID(ctx context.Context, id string, expectedVersion *int64) (models.Review, error) {
+	update := models.ReviewUpdateData{
+		IsDeleted:       models.BoolPtr(true),
+		ExpectedVersion: expectedVersion,
 	}
 
-	updatedReview, err := uc.repo.Update(ctx, id, update)
+	existing, deletedReview, err := uc.write(ctx, id, update)
+	if errors.Is(err, models.ErrReviewNotFound) || errors.Is(err, models.ErrVersionConflict) {
+		return models.Review{}, err
+	}
 	if err != nil {
-		uc.log.Error("failed to update review", "review_id", id, "error", err)
+		logger.FromContext(ctx, uc.log).Error("failed to delete review", "review_id", id, "error", err)
 		return models.Review{}, err
 	}
 
-	return updatedReview, nil
+	uc.applyStats(ctx, &existing, &deletedReview)
+	uc.publish(models.ReviewDeleted, deletedReview)
+
+	return deletedReview, nil
+}
+
+func (uc *reviewUseCase) GetMovieAverageRating(ctx context.Context, movieID string) (models.RatingSummary, error) {
+	var summary models.RatingSummary
+
+	stats, err := uc.statsRepo.FindByMovieID(ctx, movieID)
+	switch {
+	case err == nil:
+		summary = stats.Summary()
+	case errors.Is(err, models.ErrMovieStatsNotFound):
+		// Movies without reviews have no stats, and invalidated stats
+		// are not returned until the next rebuild.
+		summary, err = uc.repo.GetAverageRating(ctx, movieID)
+		if err != nil {
+			return models.RatingSummary{}, err
+		}
+	default:
+		return models.RatingSummary{}, err
+	}
+
+	summary.WeightedScore = uc.scoring.Score(summary.AverageRating, summary.ReviewCount)
+
+	return summary, nil
 }
 
-func (uc *reviewUseCase) DeleteByID(ctx context.Context, id string) (models.Review, error) {
-	existing, err := uc.repo.FindByID(ctx, id)
+func (uc *reviewUseCase) GetTopRatedMovies(ctx context.Context, limit int) ([]models.RatingSummary, error) {
+	if limit == 0 {
+		limit = models.DefaultLimit
+	}
+
+	leaderboard, err := uc.GetMovieLeaderboard(ctx, models.LeaderboardQuery{
+		SortBy: models.LeaderboardSortWeighted,
+		Limit:  limit,
+	})
 	if err != nil {
-		return models.Review{}, err
+		return nil, err
 	}
 
-	if existing.IsDeleted {
-		return models.Review{}, models.ErrReviewNotFound
+	summaries := make([]models.RatingSummary, 0, len(leaderboard.Entries))
+	for _, entry := range leaderboard.Entries {
+		summaries = append(summaries, entry.RatingSummary)
 	}
 
-	update := models.ReviewUpdateData{
-		IsDeleted: models.BoolPtr(true),
+	return summaries, nil
+}
+
+func (uc *reviewUseCase) GetMovieLeaderboard(ctx context.Context, query models.LeaderboardQuery) (models.Leaderboard, error) {
+	if query.SortBy == "" {
+		query.SortBy = models.LeaderboardSortWeighted
 	}
 
-	deletedReview, err := uc.repo.Update(ctx, id, update)
-	if err != nil {
-		uc.log.Error("failed to delete review", "review_id", id, "error", err)
-		return models.Review{}, err
+	if query.Limit == 0 {
+		query.Limit = models.DefaultLimit
 	}
 
-	return deletedReview, nil
+	if err := query.Validate(); err != nil {
+		return models.Leaderboard{}, err
+	}
+
+	var since *time.Time
+	if query.Window > 0 {
+		from := time.Now().Add(-query.Window)
+		since = &from
+	}
+
+	return uc.repo.GetMovieLeaderboard(ctx, query, since, uc.scoring)
 }
 
-func (uc *reviewUseCase) GetMovieAverageRating(ctx context.Context, movieID string) (float64, error) {
-	average, err := uc.repo.GetAverageRating(ctx, movieID)
-	if err != nil {
-		return 0, err
+func (uc *reviewUseCase) GetUserReviewStats(ctx context.Context, userID string) (models.UserReviewStats, error) {
+	if userID == "" {
+		return models.UserReviewStats{}, models.ErrInvalidInput
+	}
+
+	return uc.repo.GetUserReviewStats(ctx, userID)
+}
+
+func (uc *reviewUseCase) GetMovieRatingTimeline(ctx context.Context, query models.TimelineQuery) ([]models.RatingBucket, error) {
+	if query.MovieID == "" {
+		return nil, models.ErrInvalidInput
+	}
+
+	if query.To.IsZero() {
+		query.To = time.Now()
+	}
+
+	if query.From.IsZero() {
+		query.From = query.To.AddDate(0, 0, -defaultTimelineDays)
+	}
+
+	if !query.From.Before(query.To) {
+		return nil, models.ErrInvalidTimeRange
+	}
+
+	var bucket time.Duration
+	switch query.BucketSize {
+	case models.BucketDay:
+		bucket = 24 * time.Hour
+	case models.BucketWeek:
+		bucket = 7 * 24 * time.Hour
+	case models.BucketMonth:
+		bucket = 28 * 24 * time.Hour
+	default:
+		return nil, models.ErrInvalidBucketSize
+	}
+
+	if query.To.Sub(query.From)/bucket > maxTimelineBuckets {
+		return nil, models.ErrTooManyBuckets
+	}
+
+	return uc.repo.GetMovieRatingTimeline(ctx, query)
+}
+
+// SubscribeMovieReviews returns the live events of a movie's reviews and a
+// function that ends the subscription.
+func (uc *reviewUseCase) SubscribeMovieReviews(ctx context.Context, movieID string) (<-chan models.ReviewEvent, func(), error) {
+	if movieID == "" {
+		return nil, nil, models.ErrInvalidInput
+	}
+
+	events, unsubscribe := uc.subscriber.Subscribe(movieID)
+
+	return events, unsubscribe, nil
+}
+
+func (uc *reviewUseCase) publish(eventType models.ReviewEventType, review models.Review) {
+	if uc.publisher == nil {
+		return
+	}
+
+	uc.publisher.Publish(models.ReviewEvent{
+		Type:       eventType,
+		Review:     review,
+		OccurredAt: time.Now(),
+	})
+}
+
+// write applies update to the review read right before it and returns both.
+// The write is guarded by the version read, so the stats delta computed
+// from the pair is exact. Without an expected version from the caller, a
+// write that loses a race is retried on a fresh read.
+func (uc *reviewUseCase) write(
+	ctx context.Context,
+	id string,
+	update models.ReviewUpdateData,
+) (existing, written models.Review, err error) {
+	expectedVersion := update.ExpectedVersion
+
+	for attempt := 1; ; attempt++ {
+		existing, err = uc.repo.FindByID(ctx, id)
+		if err != nil {
+			return models.Review{}, models.Review{}, err
+		}
+
+		if existing.IsDeleted {
+			return models.Review{}, models.Review{}, models.ErrReviewNotFound
+		}
+
+		if expectedVersion != nil && *expectedVersion != existing.Version {
+			return models.Review{}, models.Review{}, models.ErrVersionConflict
+		}
+
+		version := existing.Version
+		update.ExpectedVersion = &version
+
+		written, err = uc.repo.Update(ctx, id, update)
+		if errors.Is(err, models.ErrVersionConflict) && expectedVersion == nil && attempt < maxWriteAttempts {
+			continue
+		}
+		if err != nil {
+			return models.Review{}, models.Review{}, err
+		}
+
+		return existing, written, nil
 	}
+}
+
+// applyStats keeps the precomputed movie rating stats in line with a review
+// write. The write itself has succeeded, so a failure is not returned;
+// instead the movie's stats are invalidated so reads fall back to the
+// reviews collection until the next review-stats rebuild.
+func (uc *reviewUseCase) applyStats(ctx context.Context, before, after *models.Review) {
+	delta := models.NewMovieRatingStatsDelta(before, after)
 
-	return average, nil
+	if err := uc.statsRepo.Apply(ctx, delta); err != nil {
+		invalidateStats(ctx, uc.statsRepo, logger.FromContext(ctx, uc.log), delta.MovieID, err)
+	}
+}
+
+// invalidateStats marks the stats of a movie whose update failed with cause
+// as stale. Only a failure to mark them leaves wrong stats readable until
+// the next review-stats rebuild.
+func invalidateStats(ctx context.Context, repo RatingStatsRepository, log *slog.Logger, movieID string, cause error) {
+	log.Warn("failed to update movie rating stats, invalidating them", "movie_id", movieID, "error", cause)
+
+	if err := repo.Invalidate(ctx, movieID); err != nil {
+		log.Error("failed to invalidate movie rating stats, run review-stats rebuild", "movie_id", movieID, "error", err)
+	}
 }
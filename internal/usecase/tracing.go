@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"ap2final_review_service/internal/models"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "ap2final_review_service/internal/usecase"
+
+type tracedReviewUseCase struct {
+	next   ReviewUseCase
+	tracer trace.Tracer
+}
+
+// NewTracedReviewUseCase wraps next, opening a span around every call.
+// Expected outcomes such as a missing review or invalid input are not
+// marked as span errors.
+func NewTracedReviewUseCase(next ReviewUseCase) ReviewUseCase {
+	return &tracedReviewUseCase{
+		next:   next,
+		tracer: otel.Tracer(tracerName),
+	}
+}
+
+func (uc *tracedReviewUseCase) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, func(*error)) {
+	ctx, span := uc.tracer.Start(ctx, "reviewUseCase."+method, trace.WithAttributes(attrs...))
+
+	return ctx, func(err *error) {
+		if *err != nil && !isExpected(*err) {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
+
+func isExpected(err error) bool {
+	return errors.Is(err, models.ErrReviewNotFound) ||
+		errors.Is(err, models.ErrReviewAlreadyExists) ||
+		errors.Is(err, models.ErrVersionConflict) ||
+		errors.Is(err, models.ErrInvalidInput) ||
+		errors.Is(err, context.Canceled)
+}
+
+func (uc *tracedReviewUseCase) Create(ctx context.Context, review models.Review) (res models.Review, err error) {
+	ctx, end := uc.start(ctx, "Create", attribute.String("movie.id", review.MovieID))
+	defer end(&err)
+
+	return uc.next.Create(ctx, review)
+}
+
+func (uc *tracedReviewUseCase) GetByID(ctx context.Context, id string) (res models.Review, err error) {
+	ctx, end := uc.start(ctx, "GetByID", attribute.String("review.id", id))
+	defer end(&err)
+
+	return uc.next.GetByID(ctx, id)
+}
+
+func (uc *tracedReviewUseCase) BatchGet(ctx context.Context, ids []string) (res []models.Review, notFound []string, err error) {
+	ctx, end := uc.start(ctx, "BatchGet", attribute.Int("review.count", len(ids)))
+	defer end(&err)
+
+	return uc.next.BatchGet(ctx, ids)
+}
+
+func (uc *tracedReviewUseCase) GetAll(ctx context.Context) (res []models.Review, err error) {
+	ctx, end := uc.start(ctx, "GetAll")
+	defer end(&err)
+
+	return uc.next.GetAll(ctx)
+}
+
+func (uc *tracedReviewUseCase) StreamReviews(
+	ctx context.Context,
+	filter models.ReviewFilter,
+	fn func(models.Review) error,
+) (err error) {
+	ctx, end := uc.start(ctx, "StreamReviews")
+	defer end(&err)
+
+	return uc.next.StreamReviews(ctx, filter, fn)
+}
+
+func (uc *tracedReviewUseCase) GetByUserID(ctx context.Context, userID string) (res []models.Review, err error) {
+	ctx, end := uc.start(ctx, "GetByUserID")
+	defer end(&err)
+
+	return uc.next.GetByUserID(ctx, userID)
+}
+
+func (uc *tracedReviewUseCase) GetByMovieID(ctx context.Context, movieID string) (res []models.Review, err error) {
+	ctx, end := uc.start(ctx, "GetByMovieID", attribute.String("movie.id", movieID))
+	defer end(&err)
+
+	return uc.next.GetByMovieID(ctx, movieID)
+}
+
+func (uc *tracedReviewUseCase) UpdateByID(ctx context.Context, id string, update models.ReviewUpdateData) (res models.Review, err error) {
+	ctx, end := uc.start(ctx, "UpdateByID", attribute.String("review.id", id))
+	defer end(&err)
+
+	return uc.next.UpdateByID(ctx, id, update)
+}
+
+func (uc *tracedReviewUseCase) DeleteByID(ctx context.Context, id string, expectedVersion *int64) (res models.Review, err error) {
+	ctx, end := uc.start(ctx, "DeleteByID", attribute.String("review.id", id))
+	defer end(&err)
+
+	return uc.next.DeleteByID(ctx, id, expectedVersion)
+}
+
+func (uc *tracedReviewUseCase) GetMovieAverageRating(ctx context.Context, movieID string) (res models.RatingSummary, err error) {
+	ctx, end := uc.start(ctx, "GetMovieAverageRating", attribute.String("movie.id", movieID))
+	defer end(&err)
+
+	return uc.next.GetMovieAverageRating(ctx, movieID)
+}
+
+func (uc *tracedReviewUseCase) GetTopRatedMovies(ctx context.Context, limit int) (res []models.RatingSummary, err error) {
+	ctx, end := uc.start(ctx, "GetTopRatedMovies")
+	defer end(&err)
+
+	return uc.next.GetTopRatedMovies(ctx, limit)
+}
+
+func (uc *tracedReviewUseCase) GetMovieLeaderboard(ctx context.Context, query models.LeaderboardQuery) (res models.Leaderboard, err error) {
+	ctx, end := uc.start(ctx, "GetMovieLeaderboard", attribute.String("leaderboard.sort", string(query.SortBy)))
+	defer end(&err)
+
+	return uc.next.GetMovieLeaderboard(ctx, query)
+}
+
+func (uc *tracedReviewUseCase) GetUserReviewStats(ctx context.Context, userID string) (res models.UserReviewStats, err error) {
+	ctx, end := uc.start(ctx, "GetUserReviewStats")
+	defer end(&err)
+
+	return uc.next.GetUserReviewStats(ctx, userID)
+}
+
+func (uc *tracedReviewUseCase) GetMovieRatingTimeline(ctx context.Context, query models.TimelineQuery) (res []models.RatingBucket, err error) {
+	ctx, end := uc.start(ctx, "GetMovieRatingTimeline", attribute.String("movie.id", query.MovieID))
+	defer end(&err)
+
+	return uc.next.GetMovieRatingTimeline(ctx, query)
+}
+
+// SubscribeMovieReviews only traces setting up the subscription, the events
+// arrive long after the span has ended.
+func (uc *tracedReviewUseCase) SubscribeMovieReviews(
+	ctx context.Context,
+	movieID string,
+) (events <-chan models.ReviewEvent, unsubscribe func(), err error) {
+	_, end := uc.start(ctx, "SubscribeMovieReviews", attribute.String("movie.id", movieID))
+	defer end(&err)
+
+	return uc.next.SubscribeMovieReviews(ctx, movieID)
+}
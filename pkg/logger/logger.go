@@ -1,7 +1,7 @@
 package logger
 
 import (
-	"github.com/sorawaslocked/ap2final_base/pkg/logger/slogpretty"
+	"ap2final_review_service/pkg/logger/slogpretty"
 	"log/slog"
 	"os"
 )
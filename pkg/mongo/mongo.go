@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Config struct {
@@ -17,10 +18,12 @@ type DB struct {
 	Client     *mongo.Client
 }
 
-func NewDB(ctx context.Context, cfg Config) (*DB, error) {
-	clientOpts := cfg.clientOptions()
+// NewDB connects to the configured database. opts are applied after the
+// options derived from cfg, e.g. to set a command monitor.
+func NewDB(ctx context.Context, cfg Config, opts ...*options.ClientOptions) (*DB, error) {
+	clientOpts := append([]*options.ClientOptions{cfg.clientOptions()}, opts...)
 
-	client, err := mongo.Connect(ctx, clientOpts)
+	client, err := mongo.Connect(ctx, clientOpts...)
 	if err != nil {
 		return nil, connectionError(err)
 	}